@@ -3,18 +3,26 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 	"time"
 
 	"github.com/jo-hoe/whatsapp-reminder/internal/app"
 	"github.com/jo-hoe/whatsapp-reminder/internal/config"
+	"github.com/jo-hoe/whatsapp-reminder/internal/duration"
+	"github.com/jo-hoe/whatsapp-reminder/internal/service/configstore"
 )
 
 func main() {
 	configPath := flag.String("config", "", "Path to configuration file (default: ./config.yaml)")
+	exportPath := flag.String("export-ics", "", "Export pending reminders of the selected source as iCalendar file to this path instead of sending reminders")
+	listBackups := flag.Bool("list-backups", false, "List the snapshots of the sheet instead of sending reminders")
+	restoreBackup := flag.String("restore-backup", "", "Replace the sheet with this snapshot (or 'latest') instead of sending reminders")
+	sourceName := flag.String("source", "", "Name of the source to export, list or restore backups of, required if multiple sources are configured")
 	flag.Parse()
 
 	log.Println("starting WhatsApp Reminder CLI...")
@@ -38,14 +46,49 @@ func main() {
 
 	log.Printf("configuration loaded successfully")
 
-	appConfig, err := createAppConfig(ctx, cfg)
+	appConfigs, err := createAppConfigs(ctx, cfg)
 	if err != nil {
 		log.Fatalf("failed to create app configuration: %v", err)
 	}
 
+	if *listBackups {
+		appConfig, err := app.FindSource(appConfigs, *sourceName)
+		if err != nil {
+			log.Fatalf("failed to list backups: %v", err)
+		}
+		if err := app.ListBackups(appConfig, os.Stdout); err != nil {
+			log.Fatalf("failed to list backups: %v", err)
+		}
+		return
+	}
+
+	if *restoreBackup != "" {
+		appConfig, err := app.FindSource(appConfigs, *sourceName)
+		if err != nil {
+			log.Fatalf("failed to restore backup: %v", err)
+		}
+		if err := app.RestoreBackup(appConfig, *restoreBackup); err != nil {
+			log.Fatalf("failed to restore backup: %v", err)
+		}
+		log.Printf("restored snapshot %s", *restoreBackup)
+		return
+	}
+
+	if *exportPath != "" {
+		appConfig, err := app.FindSource(appConfigs, *sourceName)
+		if err != nil {
+			log.Fatalf("failed to export calendar: %v", err)
+		}
+		if err := exportCalendar(appConfig, *exportPath); err != nil {
+			log.Fatalf("failed to export calendar: %v", err)
+		}
+		log.Printf("calendar exported to %s", *exportPath)
+		return
+	}
+
 	log.Println("running reminder...")
 	start := time.Now()
-	err = app.Start(appConfig)
+	err = app.StartAll(appConfigs)
 	duration := time.Since(start)
 
 	if err != nil {
@@ -60,6 +103,22 @@ func main() {
 	log.Printf("reminder execution completed successfully in %v", duration)
 }
 
+// createAppConfigs creates the configuration of each source
+func createAppConfigs(ctx context.Context, cfg *config.Config) ([]*app.AppConfig, error) {
+	result := make([]*app.AppConfig, 0)
+	for _, source := range cfg.GetSources() {
+		appConfig, err := createAppConfig(ctx, &source)
+		if err != nil {
+			if source.Name != "" {
+				return nil, fmt.Errorf("source '%s': %w", source.Name, err)
+			}
+			return nil, err
+		}
+		result = append(result, appConfig)
+	}
+	return result, nil
+}
+
 func createAppConfig(ctx context.Context, cfg *config.Config) (*app.AppConfig, error) {
 	timeLocation, err := time.LoadLocation(cfg.App.TimeLocation)
 	if err != nil {
@@ -71,18 +130,97 @@ func createAppConfig(ctx context.Context, cfg *config.Config) (*app.AppConfig, e
 		return nil, err
 	}
 
-	serviceAccountSecret, err := cfg.GetServiceAccountSecret()
+	leadTimes, err := duration.ParseList(cfg.App.LeadTimes)
+	if err != nil {
+		return nil, err
+	}
+
+	quietHours, err := cfg.GetQuietHours(timeLocation)
+	if err != nil {
+		return nil, err
+	}
+
+	maxLateness, err := cfg.GetMaxLateness()
+	if err != nil {
+		return nil, err
+	}
+
+	escalationDelay, err := cfg.GetEscalationDelay()
+	if err != nil {
+		return nil, err
+	}
+
+	actionValidity, err := cfg.GetActionValidity()
+	if err != nil {
+		return nil, err
+	}
+
+	businessDays, err := cfg.GetBusinessDayRules()
+	if err != nil {
+		return nil, err
+	}
+
+	timeFormat, err := cfg.GetTimeFormat()
+	if err != nil {
+		return nil, err
+	}
+
+	columns, err := configstore.NewColumns(cfg.GoogleSheets.ColumnAliases)
+	if err != nil {
+		return nil, fmt.Errorf("invalid googleSheets.columnAliases: %w", err)
+	}
+
+	writeMode, err := configstore.ParseWriteMode(cfg.GoogleSheets.WriteMode)
+	if err != nil {
+		return nil, fmt.Errorf("invalid googleSheets.writeMode: %w", err)
+	}
+
+	googleCredentials, err := cfg.GetGoogleCredentials(ctx, true)
 	if err != nil {
 		return nil, err
 	}
 
 	return &app.AppConfig{
-		Ctx:                  ctx,
-		SpreadSheetId:        cfg.GoogleSheets.SpreadsheetID,
-		SheetName:            cfg.GoogleSheets.SheetName,
-		ServiceAccountSecret: serviceAccountSecret,
-		RetentionTime:        retentionTime,
-		TimeLocation:         timeLocation,
-		Email:                cfg.Email,
+		Ctx:                ctx,
+		SourceName:         cfg.Name,
+		SpreadSheetId:      cfg.GoogleSheets.SpreadsheetID,
+		SheetName:          cfg.GoogleSheets.SheetName,
+		Columns:            columns,
+		ErrorSheetName:     cfg.GoogleSheets.ErrorSheetName,
+		WriteMode:          writeMode,
+		Forms:              cfg.GoogleSheets.Forms,
+		GoogleCredentials:  googleCredentials,
+		RetentionTime:      retentionTime,
+		TimeLocation:       timeLocation,
+		TimeFormat:         timeFormat,
+		DefaultCountryCode: cfg.App.DefaultCountryCode,
+		LeadTimes:          leadTimes,
+		QuietHours:         quietHours,
+		BusinessDays:       businessDays,
+		MaxLateness:        maxLateness,
+		CatchUpDigest:      cfg.App.CatchUpDigest,
+		Escalation:         cfg.App.Escalation,
+		EscalationDelay:    escalationDelay,
+		Email:              cfg.Email,
+		Contacts:           cfg.Contacts,
+		Actions:            cfg.Actions,
+		Backup:             cfg.Backup,
+		Archive:            cfg.Archive,
+		ActionValidity:     actionValidity,
 	}, nil
 }
+
+func exportCalendar(appConfig *app.AppConfig, path string) (err error) {
+	file, err := os.Create(filepath.Clean(path))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		cerr := file.Close()
+		if err == nil {
+			err = cerr
+		}
+	}()
+
+	return app.ExportCalendar(appConfig, file)
+}
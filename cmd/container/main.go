@@ -12,10 +12,16 @@ import (
 
 	"github.com/jo-hoe/whatsapp-reminder/internal/app"
 	"github.com/jo-hoe/whatsapp-reminder/internal/config"
+	"github.com/jo-hoe/whatsapp-reminder/internal/duration"
+	"github.com/jo-hoe/whatsapp-reminder/internal/service/configstore"
 )
 
 func main() {
 	configPath := flag.String("config", "", "Path to configuration file (default: /app/config.yaml or ./config.yaml)")
+	serve := flag.Bool("serve", false, "Serve the endpoint for action links in reminder emails instead of sending reminders")
+	listBackups := flag.Bool("list-backups", false, "List the snapshots of the sheet instead of sending reminders")
+	restoreBackup := flag.String("restore-backup", "", "Replace the sheet with this snapshot (or 'latest') instead of sending reminders")
+	sourceName := flag.String("source", "", "Name of the source to list or restore backups of, required if multiple sources are configured")
 	flag.Parse()
 
 	if *configPath == "" {
@@ -45,17 +51,50 @@ func main() {
 
 	log.Printf("configuration loaded successfully")
 
-	appConfig, err := createAppConfig(ctx, cfg)
+	appConfigs, err := createAppConfigs(ctx, cfg)
 	if err != nil {
 		log.Fatalf("failed to create app configuration: %v", err)
 	}
 
-	if err := validateConfig(appConfig); err != nil {
-		log.Fatalf("configuration validation failed: %v", err)
+	for _, appConfig := range appConfigs {
+		if err := validateConfig(appConfig); err != nil {
+			log.Fatalf("configuration validation failed: %v", err)
+		}
+	}
+
+	if *listBackups {
+		appConfig, err := app.FindSource(appConfigs, *sourceName)
+		if err != nil {
+			log.Fatalf("failed to list backups: %v", err)
+		}
+		if err := app.ListBackups(appConfig, os.Stdout); err != nil {
+			log.Fatalf("failed to list backups: %v", err)
+		}
+		return
+	}
+
+	if *restoreBackup != "" {
+		appConfig, err := app.FindSource(appConfigs, *sourceName)
+		if err != nil {
+			log.Fatalf("failed to restore backup: %v", err)
+		}
+		if err := app.RestoreBackup(appConfig, *restoreBackup); err != nil {
+			log.Fatalf("failed to restore backup: %v", err)
+		}
+		log.Printf("restored snapshot %s", *restoreBackup)
+		return
+	}
+
+	if *serve {
+		if err := app.ServeActions(appConfigs); err != nil {
+			log.Fatalf("action endpoint failed: %v", err)
+		}
+		log.Println("action endpoint stopped, exiting")
+		return
 	}
 
 	log.Println("executing reminder...")
-	if err := runReminder(appConfig); err != nil {
+	if err := runReminder(appConfigs); err != nil {
 		if ctx.Err() == context.Canceled {
 			log.Println("reminder execution cancelled due to shutdown signal")
 			os.Exit(0)
@@ -67,6 +106,22 @@ func main() {
 	log.Println("container execution completed successfully, exiting")
 }
 
+// createAppConfigs creates the configuration of each source
+func createAppConfigs(ctx context.Context, cfg *config.Config) ([]*app.AppConfig, error) {
+	result := make([]*app.AppConfig, 0)
+	for _, source := range cfg.GetSources() {
+		appConfig, err := createAppConfig(ctx, &source)
+		if err != nil {
+			if source.Name != "" {
+				return nil, fmt.Errorf("source '%s': %w", source.Name, err)
+			}
+			return nil, err
+		}
+		result = append(result, appConfig)
+	}
+	return result, nil
+}
+
 func createAppConfig(ctx context.Context, cfg *config.Config) (*app.AppConfig, error) {
 	timeLocation, err := time.LoadLocation(cfg.App.TimeLocation)
 	if err != nil {
@@ -78,19 +133,84 @@ func createAppConfig(ctx context.Context, cfg *config.Config) (*app.AppConfig, e
 		return nil, err
 	}
 
-	serviceAccountSecret, err := cfg.GetServiceAccountSecret()
+	leadTimes, err := duration.ParseList(cfg.App.LeadTimes)
+	if err != nil {
+		return nil, err
+	}
+
+	quietHours, err := cfg.GetQuietHours(timeLocation)
+	if err != nil {
+		return nil, err
+	}
+
+	maxLateness, err := cfg.GetMaxLateness()
+	if err != nil {
+		return nil, err
+	}
+
+	escalationDelay, err := cfg.GetEscalationDelay()
+	if err != nil {
+		return nil, err
+	}
+
+	actionValidity, err := cfg.GetActionValidity()
+	if err != nil {
+		return nil, err
+	}
+
+	businessDays, err := cfg.GetBusinessDayRules()
+	if err != nil {
+		return nil, err
+	}
+
+	timeFormat, err := cfg.GetTimeFormat()
+	if err != nil {
+		return nil, err
+	}
+
+	columns, err := configstore.NewColumns(cfg.GoogleSheets.ColumnAliases)
+	if err != nil {
+		return nil, fmt.Errorf("invalid googleSheets.columnAliases: %w", err)
+	}
+
+	writeMode, err := configstore.ParseWriteMode(cfg.GoogleSheets.WriteMode)
+	if err != nil {
+		return nil, fmt.Errorf("invalid googleSheets.writeMode: %w", err)
+	}
+
+	// the container cannot open a browser, oauth needs a token cached with the cli
+	googleCredentials, err := cfg.GetGoogleCredentials(ctx, false)
 	if err != nil {
 		return nil, err
 	}
 
 	return &app.AppConfig{
-		Ctx:                  ctx,
-		SpreadSheetId:        cfg.GoogleSheets.SpreadsheetID,
-		SheetName:            cfg.GoogleSheets.SheetName,
-		ServiceAccountSecret: serviceAccountSecret,
-		RetentionTime:        retentionTime,
-		TimeLocation:         timeLocation,
-		Email:                cfg.Email,
+		Ctx:                ctx,
+		SourceName:         cfg.Name,
+		SpreadSheetId:      cfg.GoogleSheets.SpreadsheetID,
+		SheetName:          cfg.GoogleSheets.SheetName,
+		Columns:            columns,
+		ErrorSheetName:     cfg.GoogleSheets.ErrorSheetName,
+		WriteMode:          writeMode,
+		Forms:              cfg.GoogleSheets.Forms,
+		GoogleCredentials:  googleCredentials,
+		RetentionTime:      retentionTime,
+		TimeLocation:       timeLocation,
+		TimeFormat:         timeFormat,
+		DefaultCountryCode: cfg.App.DefaultCountryCode,
+		LeadTimes:          leadTimes,
+		QuietHours:         quietHours,
+		BusinessDays:       businessDays,
+		MaxLateness:        maxLateness,
+		CatchUpDigest:      cfg.App.CatchUpDigest,
+		Escalation:         cfg.App.Escalation,
+		EscalationDelay:    escalationDelay,
+		Email:              cfg.Email,
+		Contacts:           cfg.Contacts,
+		Actions:            cfg.Actions,
+		Backup:             cfg.Backup,
+		Archive:            cfg.Archive,
+		ActionValidity:     actionValidity,
 	}, nil
 }
 
@@ -101,15 +221,15 @@ func validateConfig(appConfig *app.AppConfig) error {
 	if appConfig.SheetName == "" {
 		return fmt.Errorf("sheet name is required")
 	}
-	if len(appConfig.ServiceAccountSecret) == 0 {
-		return fmt.Errorf("service account secret is required")
+	if len(appConfig.GoogleCredentials) == 0 {
+		return fmt.Errorf("google credentials are required")
 	}
 	return nil
 }
 
-func runReminder(config *app.AppConfig) error {
+func runReminder(configs []*app.AppConfig) error {
 	start := time.Now()
-	err := app.Start(config)
+	err := app.StartAll(configs)
 	duration := time.Since(start)
 
 	if err != nil {
@@ -4,6 +4,7 @@ go 1.26.0
 
 require (
 	github.com/jo-hoe/google-sheets v1.0.1
+	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
 	gopkg.in/yaml.v2 v2.4.0
 )
 
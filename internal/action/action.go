@@ -0,0 +1,139 @@
+package action
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// actions let recipients acknowledge or snooze a reminder from the email.
+// each link carries a token with the action, the entry and an expiry which
+// is signed with HMAC-SHA256 so that links cannot be forged or altered.
+
+type Kind string
+
+const (
+	Done   Kind = "done"
+	Snooze Kind = "snooze"
+)
+
+// ErrNotFound is returned by executors if the entry of an action does not exist anymore
+var ErrNotFound = errors.New("reminder not found")
+
+type Action struct {
+	Kind Kind
+	// EntryID identifies the entry in the config store
+	EntryID string
+	// Snooze is the duration to postpone the reminder by
+	Snooze  time.Duration
+	Expires time.Time
+}
+
+// Executor applies actions to the entries of a config store
+type Executor interface {
+	Execute(action Action) error
+}
+
+// Executors applies an action with the first executor knowing its entry,
+// e.g. with one executor per source. A failing executor does not stop the
+// others, ErrNotFound is only returned if all of them do not know the entry.
+type Executors []Executor
+
+func (executors Executors) Execute(action Action) error {
+	errs := make([]error, 0)
+	for i, executor := range executors {
+		err := executor.Execute(action)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, ErrNotFound) {
+			log.Printf("could not execute action %s in source %d: %v", action.Kind, i+1, err)
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	return ErrNotFound
+}
+
+// MinSecretLength is the minimum length of the secret signing the links
+const MinSecretLength = 16
+
+type Signer struct {
+	secret []byte
+}
+
+func NewSigner(secret []byte) *Signer {
+	return &Signer{secret: secret}
+}
+
+// Sign creates a URL safe token for the action
+func (signer *Signer) Sign(action Action) string {
+	payload := strings.Join([]string{
+		string(action.Kind),
+		action.EntryID,
+		strconv.FormatInt(int64(action.Snooze/time.Second), 10),
+		strconv.FormatInt(action.Expires.Unix(), 10),
+	}, ":")
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(signer.mac([]byte(payload)))
+}
+
+// Verify returns the action of a token if its signature is valid and it has not expired
+func (signer *Signer) Verify(token string, now time.Time) (Action, error) {
+	encodedPayload, encodedSignature, found := strings.Cut(token, ".")
+	if !found {
+		return Action{}, fmt.Errorf("malformed token")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return Action{}, fmt.Errorf("malformed token: %w", err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil {
+		return Action{}, fmt.Errorf("malformed token: %w", err)
+	}
+	if !hmac.Equal(signature, signer.mac(payload)) {
+		return Action{}, fmt.Errorf("invalid token signature")
+	}
+
+	fields := strings.Split(string(payload), ":")
+	if len(fields) != 4 {
+		return Action{}, fmt.Errorf("malformed token")
+	}
+	snooze, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		return Action{}, fmt.Errorf("malformed token: %w", err)
+	}
+	expires, err := strconv.ParseInt(fields[3], 10, 64)
+	if err != nil {
+		return Action{}, fmt.Errorf("malformed token: %w", err)
+	}
+
+	result := Action{
+		Kind:    Kind(fields[0]),
+		EntryID: fields[1],
+		Snooze:  time.Duration(snooze) * time.Second,
+		Expires: time.Unix(expires, 0),
+	}
+	if now.After(result.Expires) {
+		return Action{}, fmt.Errorf("token expired at %v", result.Expires)
+	}
+	if result.Kind != Done && result.Kind != Snooze {
+		return Action{}, fmt.Errorf("unknown action '%s'", result.Kind)
+	}
+	return result, nil
+}
+
+func (signer *Signer) mac(payload []byte) []byte {
+	hash := hmac.New(sha256.New, signer.secret)
+	hash.Write(payload)
+	return hash.Sum(nil)
+}
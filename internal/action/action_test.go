@@ -0,0 +1,62 @@
+package action
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSigner_Verify(t *testing.T) {
+	now := time.Date(2024, 3, 8, 12, 0, 0, 0, time.UTC)
+	signer := NewSigner([]byte("secret"))
+	expected := Action{Kind: Snooze, EntryID: "abc", Snooze: time.Hour, Expires: now.Add(time.Hour)}
+	token := signer.Sign(expected)
+
+	tests := []struct {
+		name    string
+		signer  *Signer
+		token   string
+		now     time.Time
+		wantErr bool
+	}{
+		{name: "valid", signer: signer, token: token, now: now},
+		{name: "expired", signer: signer, token: token, now: now.Add(2 * time.Hour), wantErr: true},
+		{name: "other secret", signer: NewSigner([]byte("other")), token: token, now: now, wantErr: true},
+		{name: "altered", signer: signer, token: "x" + token, now: now, wantErr: true},
+		{name: "malformed", signer: signer, token: "token", now: now, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := tt.signer.Verify(tt.token, tt.now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && (actual.Kind != expected.Kind || actual.EntryID != expected.EntryID ||
+				actual.Snooze != expected.Snooze || !actual.Expires.Equal(expected.Expires)) {
+				t.Errorf("Verify() = %+v, want %+v", actual, expected)
+			}
+		})
+	}
+}
+
+func TestLinkCreator_CreateLinks(t *testing.T) {
+	now := time.Now()
+	signer := NewSigner([]byte("secret"))
+	creator := NewLinkCreator("https://reminder.example.com/", signer, 24*time.Hour)
+
+	links := creator.CreateLinks("abc", now)
+
+	if len(links) != 3 {
+		t.Fatalf("expected 3 links but got %+v", links)
+	}
+	for _, link := range links {
+		token, found := strings.CutPrefix(link.URL, "https://reminder.example.com/action?token=")
+		if !found {
+			t.Errorf("unexpected link %s", link.URL)
+		}
+		if _, err := signer.Verify(token, now); err != nil {
+			t.Errorf("found error %+v for link %s", err, link.Label)
+		}
+	}
+}
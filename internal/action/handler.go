@@ -0,0 +1,94 @@
+package action
+
+import (
+	_ "embed"
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/jo-hoe/whatsapp-reminder/internal/duration"
+)
+
+// opening a link only shows a confirmation page, the action is executed on
+// submit. this way link previews and scanners of mail providers, which
+// follow links in mails, do not execute actions.
+
+//go:embed template_page.html
+var pageTemplate string
+
+var page = template.Must(template.New("page").Parse(pageTemplate))
+
+type pageData struct {
+	Message string
+	Path    string
+	Token   string
+	Button  string
+}
+
+type Handler struct {
+	signer   *Signer
+	executor Executor
+}
+
+func NewHandler(signer *Signer, executor Executor) *Handler {
+	return &Handler{
+		signer:   signer,
+		executor: executor,
+	}
+}
+
+func (handler *Handler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet && request.Method != http.MethodPost {
+		writer.Header().Set("Allow", "GET, POST")
+		http.Error(writer, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	token := request.FormValue("token")
+	action, err := handler.signer.Verify(token, time.Now())
+	if err != nil {
+		log.Printf("rejected action: %v", err)
+		render(writer, http.StatusForbidden, pageData{Message: "This link is invalid or has expired."})
+		return
+	}
+
+	if request.Method == http.MethodGet {
+		render(writer, http.StatusOK, pageData{
+			Message: "Do you want to " + describe(action) + "?",
+			Path:    Path,
+			Token:   token,
+			Button:  "Confirm",
+		})
+		return
+	}
+
+	err = handler.executor.Execute(action)
+	if errors.Is(err, ErrNotFound) {
+		render(writer, http.StatusNotFound, pageData{Message: "The reminder does not exist anymore or was changed in the meantime."})
+		return
+	}
+	if err != nil {
+		log.Printf("could not execute action %s for entry %s: %v", action.Kind, action.EntryID, err)
+		render(writer, http.StatusInternalServerError, pageData{Message: "The reminder could not be updated, please try again later."})
+		return
+	}
+	log.Printf("executed action %s for entry %s", action.Kind, action.EntryID)
+	render(writer, http.StatusOK, pageData{Message: "Done, you wanted to " + describe(action) + "."})
+}
+
+func describe(action Action) string {
+	if action.Kind == Snooze {
+		return "snooze the reminder for " + duration.Format(action.Snooze)
+	}
+	return "mark the reminder as done"
+}
+
+func render(writer http.ResponseWriter, status int, data pageData) {
+	writer.Header().Set("Content-Type", "text/html; charset=utf-8")
+	writer.WriteHeader(status)
+	if err := page.Execute(writer, data); err != nil {
+		log.Printf("could not render page: %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+package action
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+type executorMock struct {
+	executed []Action
+	err      error
+}
+
+func (mock *executorMock) Execute(action Action) error {
+	mock.executed = append(mock.executed, action)
+	return mock.err
+}
+
+func TestHandler_ServeHTTP(t *testing.T) {
+	signer := NewSigner([]byte("secret"))
+	token := signer.Sign(Action{Kind: Done, EntryID: "abc", Expires: time.Now().Add(time.Hour)})
+
+	tests := []struct {
+		name         string
+		method       string
+		token        string
+		executorErr  error
+		wantStatus   int
+		wantExecuted int
+	}{
+		{name: "confirmation", method: http.MethodGet, token: token, wantStatus: http.StatusOK},
+		{name: "execute", method: http.MethodPost, token: token, wantStatus: http.StatusOK, wantExecuted: 1},
+		{name: "not found", method: http.MethodPost, token: token, executorErr: ErrNotFound, wantStatus: http.StatusNotFound, wantExecuted: 1},
+		{name: "invalid token", method: http.MethodPost, token: "invalid", wantStatus: http.StatusForbidden},
+		{name: "method", method: http.MethodDelete, token: token, wantStatus: http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := &executorMock{err: tt.executorErr}
+			handler := NewHandler(signer, executor)
+			form := url.Values{"token": {tt.token}}
+			request := httptest.NewRequest(tt.method, Path+"?"+form.Encode(), nil)
+			if tt.method == http.MethodPost {
+				request = httptest.NewRequest(tt.method, Path, strings.NewReader(form.Encode()))
+				request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			}
+			recorder := httptest.NewRecorder()
+
+			handler.ServeHTTP(recorder, request)
+
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+			if len(executor.executed) != tt.wantExecuted {
+				t.Errorf("executed %d action(s), want %d", len(executor.executed), tt.wantExecuted)
+			}
+		})
+	}
+}
+
+func TestExecutors_Execute(t *testing.T) {
+	missing := &executorMock{err: ErrNotFound}
+	found := &executorMock{}
+	other := &executorMock{}
+
+	if err := (Executors{missing, found, other}).Execute(Action{Kind: Done}); err != nil {
+		t.Errorf("found error %+v", err)
+	}
+	if len(missing.executed) != 1 || len(found.executed) != 1 || len(other.executed) != 0 {
+		t.Errorf("expected execution up to the executor knowing the entry")
+	}
+	if err := (Executors{missing}).Execute(Action{Kind: Done}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound but got %+v", err)
+	}
+
+	// an unreachable source does not break the other sources
+	failing := &executorMock{err: errors.New("sheet unavailable")}
+	found = &executorMock{}
+	if err := (Executors{failing, found}).Execute(Action{Kind: Done}); err != nil {
+		t.Errorf("found error %+v", err)
+	}
+	if len(found.executed) != 1 {
+		t.Errorf("expected execution by the executor after the failing one")
+	}
+	if err := (Executors{failing, missing}).Execute(Action{Kind: Done}); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("expected the error of the failing executor but got %+v", err)
+	}
+}
@@ -0,0 +1,46 @@
+package action
+
+import (
+	"net/url"
+	"strings"
+	"time"
+)
+
+const Path = "/action"
+
+type Link struct {
+	Label string
+	URL   string
+}
+
+// LinkCreator creates signed action links pointing at the action endpoint
+type LinkCreator struct {
+	baseURL  string
+	signer   *Signer
+	validity time.Duration
+}
+
+func NewLinkCreator(baseURL string, signer *Signer, validity time.Duration) *LinkCreator {
+	return &LinkCreator{
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
+		signer:   signer,
+		validity: validity,
+	}
+}
+
+// CreateLinks returns the links to mark the entry as done or to snooze it
+func (creator *LinkCreator) CreateLinks(entryID string, now time.Time) []Link {
+	expires := now.Add(creator.validity)
+	return []Link{
+		creator.createLink("Done", Action{Kind: Done, EntryID: entryID, Expires: expires}),
+		creator.createLink("Snooze 1h", Action{Kind: Snooze, EntryID: entryID, Snooze: time.Hour, Expires: expires}),
+		creator.createLink("Snooze 1 day", Action{Kind: Snooze, EntryID: entryID, Snooze: 24 * time.Hour, Expires: expires}),
+	}
+}
+
+func (creator *LinkCreator) createLink(label string, action Action) Link {
+	return Link{
+		Label: label,
+		URL:   creator.baseURL + Path + "?token=" + url.QueryEscape(creator.signer.Sign(action)),
+	}
+}
@@ -1,40 +1,317 @@
 package app
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/jo-hoe/google-sheets/gs"
+	"github.com/jo-hoe/whatsapp-reminder/internal/action"
+	"github.com/jo-hoe/whatsapp-reminder/internal/businessday"
 	"github.com/jo-hoe/whatsapp-reminder/internal/config"
+	"github.com/jo-hoe/whatsapp-reminder/internal/quiethours"
 	"github.com/jo-hoe/whatsapp-reminder/internal/service/configstore"
+	"github.com/jo-hoe/whatsapp-reminder/internal/service/contacts"
 	"github.com/jo-hoe/whatsapp-reminder/internal/service/management"
 	"github.com/jo-hoe/whatsapp-reminder/internal/service/reminder"
+	"github.com/jo-hoe/whatsapp-reminder/internal/timeformat"
+	"github.com/jo-hoe/whatsapp-reminder/internal/whatsapp"
 )
 
 type AppConfig struct {
-	Ctx                  context.Context
-	SpreadSheetId        string
-	SheetName            string
-	ServiceAccountSecret []byte
-	TimeLocation         *time.Location
-	RetentionTime        time.Duration
-	Email                config.EmailConfig
+	Ctx context.Context
+	// SourceName identifies the source in logs, empty without sources
+	SourceName     string
+	SpreadSheetId  string
+	SheetName      string
+	Columns        configstore.Columns
+	ErrorSheetName string
+	WriteMode      configstore.WriteMode
+	Forms          config.FormsConfig
+	// GoogleCredentials is the JSON document used to access the spreadsheet
+	GoogleCredentials  []byte
+	TimeLocation       *time.Location
+	TimeFormat         timeformat.Format
+	RetentionTime      time.Duration
+	DefaultCountryCode string
+	LeadTimes          []time.Duration
+	QuietHours         quiethours.QuietHours
+	BusinessDays       businessday.Rules
+	MaxLateness        time.Duration
+	CatchUpDigest      bool
+	Escalation         config.EscalationConfig
+	EscalationDelay    time.Duration
+	Email              config.EmailConfig
+	Contacts           config.ContactsConfig
+	Actions            config.ActionsConfig
+	Backup             config.BackupConfig
+	Archive            config.ArchiveConfig
+	ActionValidity     time.Duration
 }
 
 func Start(config *AppConfig) error {
-	readerCreation := func() (writer io.Reader, err error) {
-		return gs.OpenSheet(config.Ctx, config.SpreadSheetId, config.SheetName, gs.O_RDONLY, config.ServiceAccountSecret)
+	return newManager(config).Process()
+}
+
+// StartAll processes each source, a failing source does not stop the others
+func StartAll(configs []*AppConfig) error {
+	return startAll(configs, Start)
+}
+
+func startAll(configs []*AppConfig, start func(config *AppConfig) error) error {
+	errs := make([]error, 0)
+	for _, config := range configs {
+		if config.SourceName != "" {
+			log.Printf("processing source '%s'", config.SourceName)
+		}
+		if err := start(config); err != nil {
+			if config.SourceName == "" {
+				errs = append(errs, err)
+				continue
+			}
+			log.Printf("source '%s' failed: %v", config.SourceName, err)
+			errs = append(errs, fmt.Errorf("source '%s': %w", config.SourceName, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// FindSource returns the configuration of the named source. The name can be
+// empty if there is only one source.
+func FindSource(configs []*AppConfig, name string) (*AppConfig, error) {
+	if name == "" {
+		if len(configs) != 1 {
+			return nil, errors.New("multiple sources are configured, select one by its name")
+		}
+		return configs[0], nil
+	}
+	for _, config := range configs {
+		if config.SourceName == name {
+			return config, nil
+		}
+	}
+	return nil, fmt.Errorf("source '%s' not found", name)
+}
+
+// ExportCalendar writes all pending reminders as iCalendar document
+func ExportCalendar(config *AppConfig, writer io.Writer) error {
+	return newManager(config).ExportCalendar(writer)
+}
+
+// ListBackups writes the names of all snapshots of the sheet, oldest first
+func ListBackups(config *AppConfig, writer io.Writer) error {
+	backupStore, err := newBackupStore(config)
+	if err != nil {
+		return err
+	}
+	snapshots, err := backupStore.Snapshots()
+	if err != nil {
+		return err
+	}
+	for _, snapshot := range snapshots {
+		if _, err := fmt.Fprintln(writer, snapshot.Format(configstore.SnapshotLayout)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RestoreBackup replaces the sheet with the named snapshot or the newest one if the name is "latest"
+func RestoreBackup(config *AppConfig, name string) error {
+	backupStore, err := newBackupStore(config)
+	if err != nil {
+		return err
 	}
 
-	writerCreation := func() (writer io.Writer, err error) {
-		return gs.OpenSheet(config.Ctx, config.SpreadSheetId, config.SheetName, gs.O_RDWR|gs.O_TRUNC, config.ServiceAccountSecret)
+	var snapshot time.Time
+	if name == "latest" {
+		snapshots, err := backupStore.Snapshots()
+		if err != nil {
+			return err
+		}
+		if len(snapshots) == 0 {
+			return errors.New("no snapshots found")
+		}
+		snapshot = snapshots[len(snapshots)-1]
+	} else if snapshot, err = time.Parse(configstore.SnapshotLayout, name); err != nil {
+		return fmt.Errorf("invalid snapshot name '%s', use a name like %s or latest: %w", name, configstore.SnapshotLayout, err)
 	}
+	return backupStore.Restore(snapshot)
+}
 
-	store := configstore.NewCSVConfigStore(readerCreation, writerCreation, *config.TimeLocation)
+func newManager(config *AppConfig) *management.ReminderManagementService {
+	var store configstore.ConfigStore = configstore.NewCSVConfigStore(
+		sheetReader(config, config.SheetName), sheetWriter(config, config.SheetName),
+		*config.TimeLocation, config.TimeFormat, configstore.CSVConfigStoreOptions{
+			Columns:       config.Columns,
+			ErrorTab:      newErrorTab(config),
+			WriteMode:     config.WriteMode,
+			FillTimestamp: config.Forms.Enabled,
+		})
+	if target := newBackupTarget(config); target != nil {
+		store = configstore.NewBackupConfigStore(store, sheetReader(config, config.SheetName), sheetWriter(config, config.SheetName), target)
+	}
+	if config.Forms.ResponsesSheetName != "" {
+		responses := configstore.NewCSVConfigStore(sheetReader(config, config.Forms.ResponsesSheetName), nil,
+			*config.TimeLocation, config.TimeFormat, configstore.CSVConfigStoreOptions{Columns: config.Columns})
+		store = configstore.NewFormsImportConfigStore(store, responses, config.Forms.ResponsesSheetName,
+			sheetReader(config, config.Forms.ImportedSheetName), sheetWriter(config, config.Forms.ImportedSheetName))
+	}
 	mailClient := reminder.NewMailClient(config.Email)
-	reminderService := reminder.NewEmailReminderService(mailClient, config.Email.From, config.Email.To, config.Ctx)
-	manager := management.NewReminderManagementService(store, reminderService, config.RetentionTime, *config.TimeLocation)
+	reminderService := reminder.NewEmailReminderService(mailClient, config.Email, config.Ctx, newActionLinks(config))
+	options := management.Options{
+		DefaultCountryCode: config.DefaultCountryCode,
+		LinkStyle:          whatsapp.LinkStyle(config.Email.LinkStyle),
+		Contacts:           newContactSource(config),
+		LeadTimes:          config.LeadTimes,
+		QuietHours:         config.QuietHours,
+		BusinessDays:       config.BusinessDays,
+		MaxLateness:        config.MaxLateness,
+		CatchUpDigest:      config.CatchUpDigest,
+		Escalation: management.Escalation{
+			After:        config.EscalationDelay,
+			MaxResends:   config.Escalation.MaxResends,
+			AllReminders: config.Escalation.AllReminders,
+		},
+		Archive: newArchive(config),
+	}
+	return management.NewReminderManagementService(store, reminderService, config.RetentionTime, *config.TimeLocation, options)
+}
+
+// ServeActions serves the endpoint for action links of all sources until the
+// context is done. All sources have to share the endpoint settings. Actions
+// are written in merge mode, so changes of a concurrent run are only lost if
+// they happen between the read and the write of an action. Form responses are
+// only imported by the runs.
+func ServeActions(configs []*AppConfig) error {
+	if len(configs) == 0 {
+		return errors.New("no sources are configured")
+	}
+	config := configs[0]
+	executors := make(action.Executors, 0, len(configs))
+	for _, source := range configs {
+		if len(source.Actions.Secret) < action.MinSecretLength {
+			return fmt.Errorf("actions.secret must have at least %d characters to serve action links", action.MinSecretLength)
+		}
+		if source.Actions != config.Actions {
+			return errors.New("all sources must use the same actions settings")
+		}
+		serving := *source
+		serving.WriteMode = configstore.WriteModeMerge
+		serving.Forms.ResponsesSheetName = ""
+		executors = append(executors, newManager(&serving))
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(action.Path, action.NewHandler(action.NewSigner([]byte(config.Actions.Secret)), executors))
+	mux.HandleFunc("/healthz", func(writer http.ResponseWriter, _ *http.Request) {
+		writer.WriteHeader(http.StatusOK)
+	})
+
+	server := &http.Server{
+		Addr:              config.Actions.ListenAddress,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-config.Ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Printf("could not shut down action endpoint: %v", err)
+		}
+	}()
+
+	log.Printf("serving action endpoint on %s", config.Actions.ListenAddress)
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// newErrorTab returns nil if invalid rows are kept in the sheet
+func newErrorTab(config *AppConfig) *configstore.ErrorTab {
+	if config.ErrorSheetName == "" {
+		return nil
+	}
+	return configstore.NewErrorTab(sheetReader(config, config.ErrorSheetName), sheetWriter(config, config.ErrorSheetName))
+}
+
+// newArchive returns nil if expired entries are deleted
+func newArchive(config *AppConfig) configstore.Archive {
+	if config.Archive.SheetName != "" {
+		return configstore.NewCSVArchive(sheetReader(config, config.Archive.SheetName), sheetWriter(config, config.Archive.SheetName),
+			config.SourceName, *config.TimeLocation, config.TimeFormat)
+	}
+	if config.Archive.File != "" {
+		return configstore.NewFileArchive(config.Archive.File, config.SourceName, *config.TimeLocation, config.TimeFormat)
+	}
+	return nil
+}
+
+// newBackupTarget returns nil if backups are not configured
+func newBackupTarget(config *AppConfig) configstore.BackupTarget {
+	if config.Backup.SheetName != "" {
+		return configstore.NewSheetBackup(sheetReader(config, config.Backup.SheetName), sheetWriter(config, config.Backup.SheetName), config.Backup.Keep)
+	}
+	if config.Backup.Directory != "" {
+		return configstore.NewDirectoryBackup(config.Backup.Directory, config.Backup.Keep)
+	}
+	return nil
+}
+
+func newBackupStore(config *AppConfig) (*configstore.BackupConfigStore, error) {
+	target := newBackupTarget(config)
+	if target == nil {
+		return nil, errors.New("backups are not configured, set backup.directory or backup.sheetName")
+	}
+	return configstore.NewBackupConfigStore(nil, sheetReader(config, config.SheetName), sheetWriter(config, config.SheetName), target), nil
+}
+
+func sheetReader(config *AppConfig, sheetName string) func() (io.Reader, error) {
+	return func() (reader io.Reader, err error) {
+		return gs.OpenSheet(config.Ctx, config.SpreadSheetId, sheetName, gs.O_RDONLY, config.GoogleCredentials)
+	}
+}
 
-	return manager.Process()
+func sheetWriter(config *AppConfig, sheetName string) func() (io.Writer, error) {
+	return func() (writer io.Writer, err error) {
+		return gs.OpenSheet(config.Ctx, config.SpreadSheetId, sheetName, gs.O_RDWR|gs.O_TRUNC, config.GoogleCredentials)
+	}
+}
+
+// newActionLinks returns nil if action links are not configured
+func newActionLinks(config *AppConfig) *action.LinkCreator {
+	if config.Actions.BaseURL == "" {
+		return nil
+	}
+	return action.NewLinkCreator(config.Actions.BaseURL, action.NewSigner([]byte(config.Actions.Secret)), config.ActionValidity)
+}
+
+// newContactSource returns nil if no contacts are configured
+func newContactSource(config *AppConfig) contacts.ContactSource {
+	if config.Contacts.SheetName != "" {
+		return contacts.NewCSVContactSource(sheetReader(config, config.Contacts.SheetName))
+	}
+	if config.Contacts.File == "" {
+		return nil
+	}
+
+	openFile := func() (reader io.Reader, err error) {
+		data, err := os.ReadFile(filepath.Clean(config.Contacts.File))
+		return bytes.NewReader(data), err
+	}
+	extension := strings.ToLower(filepath.Ext(config.Contacts.File))
+	if extension == ".vcf" || extension == ".vcard" {
+		return contacts.NewVCardContactSource(openFile)
+	}
+	return contacts.NewCSVContactSource(openFile)
 }
@@ -0,0 +1,69 @@
+package app
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStartAll_IsolatesFailingSources(t *testing.T) {
+	configs := []*AppConfig{{SourceName: "family"}, {SourceName: "club"}, {SourceName: "work"}}
+	processed := make([]string, 0)
+	start := func(config *AppConfig) error {
+		processed = append(processed, config.SourceName)
+		if config.SourceName == "club" {
+			return errors.New("sheet not found")
+		}
+		return nil
+	}
+
+	err := startAll(configs, start)
+
+	if expected := []string{"family", "club", "work"}; !reflect.DeepEqual(processed, expected) {
+		t.Errorf("expected all sources %v to be processed but got %v", expected, processed)
+	}
+	if err == nil || !strings.Contains(err.Error(), "source 'club': sheet not found") {
+		t.Errorf("expected error of the failing source but got %v", err)
+	}
+	if strings.Contains(err.Error(), "family") || strings.Contains(err.Error(), "work") {
+		t.Errorf("expected only the failing source in the error but got %v", err)
+	}
+}
+
+func TestStartAll_WithoutSources(t *testing.T) {
+	failure := errors.New("sheet not found")
+	err := startAll([]*AppConfig{{}}, func(*AppConfig) error { return failure })
+	if !errors.Is(err, failure) || err.Error() != failure.Error() {
+		t.Errorf("expected the error unchanged but got %v", err)
+	}
+}
+
+func TestFindSource(t *testing.T) {
+	family := &AppConfig{SourceName: "family"}
+	club := &AppConfig{SourceName: "club"}
+
+	tests := []struct {
+		name    string
+		configs []*AppConfig
+		source  string
+		want    *AppConfig
+		wantErr bool
+	}{
+		{name: "single source without name", configs: []*AppConfig{family}, want: family},
+		{name: "multiple sources without name", configs: []*AppConfig{family, club}, wantErr: true},
+		{name: "by name", configs: []*AppConfig{family, club}, source: "club", want: club},
+		{name: "unknown name", configs: []*AppConfig{family, club}, source: "work", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FindSource(tt.configs, tt.source)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FindSource() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("FindSource() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package businessday
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// business day rules roll due times which fall on a weekend or public
+// holiday forward to the next business day, keeping the time of day.
+// rules are referenced by name:
+//   - "none" keeps due times as they are
+//   - "weekends" skips Saturdays and Sundays
+//   - "holidays" skips weekends and the days of the configured holiday file
+//   - a country or region code like "DE" or "DE-BY" skips weekends and
+//     the built-in public holidays of that country or region
+
+const (
+	RuleNone     = "none"
+	RuleWeekends = "weekends"
+	RuleHolidays = "holidays"
+)
+
+// maxShift limits how far a due time can be rolled forward
+const maxShift = 366
+
+// Holidays reports whether a date is a holiday
+type Holidays interface {
+	IsHoliday(date time.Time) bool
+}
+
+// Rule shifts due times to business days. The zero value keeps due times as they are.
+type Rule struct {
+	skipWeekends bool
+	holidays     Holidays
+}
+
+// Shift returns the given time on the next business day at or after it
+func (r Rule) Shift(t time.Time) time.Time {
+	for range maxShift {
+		if r.IsBusinessDay(t) {
+			return t
+		}
+		t = t.AddDate(0, 0, 1)
+	}
+	return t
+}
+
+// IsBusinessDay returns whether due times on the date of the given time are kept
+func (r Rule) IsBusinessDay(t time.Time) bool {
+	if r.skipWeekends && (t.Weekday() == time.Saturday || t.Weekday() == time.Sunday) {
+		return false
+	}
+	return r.holidays == nil || !r.holidays.IsHoliday(t)
+}
+
+// Rules resolves rules by name. The zero value keeps all due times
+// as they are unless a rule is given explicitly.
+type Rules struct {
+	defaultRule string
+	holidays    Holidays
+}
+
+// NewRules creates rules with a default rule for empty names and the holidays
+// of a holiday file used by the "holidays" rule, which may be nil
+func NewRules(defaultRule string, holidays Holidays) (Rules, error) {
+	result := Rules{
+		defaultRule: defaultRule,
+		holidays:    holidays,
+	}
+	if _, err := result.Get(""); err != nil {
+		return Rules{}, err
+	}
+	return result, nil
+}
+
+// Validate checks a rule name without reading the holiday file
+func Validate(name string, hasHolidayFile bool) error {
+	var holidays Holidays
+	if hasHolidayFile {
+		holidays = noHolidays{}
+	}
+	_, err := NewRules(name, holidays)
+	return err
+}
+
+type noHolidays struct{}
+
+func (noHolidays) IsHoliday(time.Time) bool {
+	return false
+}
+
+// Get returns the rule with the given name or the default rule if the name is empty
+func (r Rules) Get(name string) (Rule, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = r.defaultRule
+	}
+
+	switch strings.ToLower(name) {
+	case "", RuleNone:
+		return Rule{}, nil
+	case RuleWeekends:
+		return Rule{skipWeekends: true}, nil
+	case RuleHolidays:
+		if r.holidays == nil {
+			return Rule{}, fmt.Errorf("business day rule '%s' requires a holiday file", name)
+		}
+		return Rule{skipWeekends: true, holidays: r.holidays}, nil
+	}
+
+	holidays, ok := getCountryHolidays(name)
+	if !ok {
+		return Rule{}, fmt.Errorf("unknown business day rule '%s'", name)
+	}
+	return Rule{skipWeekends: true, holidays: holidays}, nil
+}
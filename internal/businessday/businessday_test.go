@@ -0,0 +1,125 @@
+package businessday
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRule_Shift(t *testing.T) {
+	location, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Errorf("found error %+v", err)
+	}
+	holidays, err := ParseHolidays(strings.NewReader(testCalendar))
+	if err != nil {
+		t.Errorf("found error %+v", err)
+	}
+	rules, err := NewRules("weekends", holidays)
+	if err != nil {
+		t.Errorf("found error %+v", err)
+	}
+
+	// 2024-03-29 is Good Friday, 2024-12-25 a Wednesday
+	tests := []struct {
+		name     string
+		rule     string
+		dueTime  time.Time
+		wantTime time.Time
+	}{
+		{name: "default weekday", rule: "", dueTime: time.Date(2024, 3, 8, 9, 30, 0, 0, location), wantTime: time.Date(2024, 3, 8, 9, 30, 0, 0, location)},
+		{name: "default weekend", rule: "", dueTime: time.Date(2024, 3, 9, 9, 30, 0, 0, location), wantTime: time.Date(2024, 3, 11, 9, 30, 0, 0, location)},
+		{name: "none", rule: "none", dueTime: time.Date(2024, 3, 9, 9, 30, 0, 0, location), wantTime: time.Date(2024, 3, 9, 9, 30, 0, 0, location)},
+		{name: "country holiday", rule: "DE", dueTime: time.Date(2024, 3, 29, 9, 30, 0, 0, location), wantTime: time.Date(2024, 4, 2, 9, 30, 0, 0, location)},
+		{name: "region holiday", rule: "de-by", dueTime: time.Date(2024, 1, 6, 9, 30, 0, 0, location), wantTime: time.Date(2024, 1, 8, 9, 30, 0, 0, location)},
+		{name: "holiday file", rule: "holidays", dueTime: time.Date(2024, 12, 24, 8, 0, 0, 0, location), wantTime: time.Date(2024, 12, 27, 8, 0, 0, 0, location)},
+		{name: "holiday file yearly", rule: "holidays", dueTime: time.Date(2025, 7, 1, 8, 0, 0, 0, location), wantTime: time.Date(2025, 7, 2, 8, 0, 0, 0, location)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := rules.Get(tt.rule)
+			if err != nil {
+				t.Errorf("found error %+v", err)
+			}
+			if got := rule.Shift(tt.dueTime); !got.Equal(tt.wantTime) {
+				t.Errorf("Shift() = %v, want %v", got, tt.wantTime)
+			}
+		})
+	}
+}
+
+func TestRules_Get_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     string
+		holidays Holidays
+	}{
+		{name: "unknown", rule: "XX"},
+		{name: "holidays without file", rule: "holidays"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewRules(tt.rule, tt.holidays); err == nil {
+				t.Errorf("expected error for rule '%s'", tt.rule)
+			}
+		})
+	}
+}
+
+func TestCountryHolidays(t *testing.T) {
+	tests := []struct {
+		country     string
+		date        time.Time
+		wantHoliday bool
+	}{
+		{country: "DE", date: time.Date(2025, 4, 21, 0, 0, 0, 0, time.UTC), wantHoliday: true},
+		{country: "DE", date: time.Date(2025, 5, 29, 0, 0, 0, 0, time.UTC), wantHoliday: true},
+		{country: "DE", date: time.Date(2025, 6, 19, 0, 0, 0, 0, time.UTC), wantHoliday: false},
+		{country: "DE-NW", date: time.Date(2025, 6, 19, 0, 0, 0, 0, time.UTC), wantHoliday: true},
+		{country: "US", date: time.Date(2025, 11, 27, 0, 0, 0, 0, time.UTC), wantHoliday: true},
+		{country: "US", date: time.Date(2025, 5, 26, 0, 0, 0, 0, time.UTC), wantHoliday: true},
+		{country: "US", date: time.Date(2026, 7, 3, 0, 0, 0, 0, time.UTC), wantHoliday: true},
+		{country: "US", date: time.Date(2020, 6, 19, 0, 0, 0, 0, time.UTC), wantHoliday: false},
+		{country: "US", date: time.Date(2021, 12, 31, 0, 0, 0, 0, time.UTC), wantHoliday: true},
+		{country: "US", date: time.Date(2020, 12, 31, 0, 0, 0, 0, time.UTC), wantHoliday: false},
+		{country: "DE-BE", date: time.Date(2019, 3, 8, 0, 0, 0, 0, time.UTC), wantHoliday: true},
+		{country: "DE-BE", date: time.Date(2018, 3, 8, 0, 0, 0, 0, time.UTC), wantHoliday: false},
+		{country: "DE-HH", date: time.Date(2018, 10, 31, 0, 0, 0, 0, time.UTC), wantHoliday: true},
+		{country: "DE-NI", date: time.Date(2016, 10, 31, 0, 0, 0, 0, time.UTC), wantHoliday: false},
+		{country: "GB", date: time.Date(2021, 12, 28, 0, 0, 0, 0, time.UTC), wantHoliday: true},
+		{country: "GB", date: time.Date(2025, 8, 25, 0, 0, 0, 0, time.UTC), wantHoliday: true},
+		{country: "NL", date: time.Date(2025, 4, 26, 0, 0, 0, 0, time.UTC), wantHoliday: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.country+" "+tt.date.Format(time.DateOnly), func(t *testing.T) {
+			holidays, ok := getCountryHolidays(tt.country)
+			if !ok {
+				t.Fatalf("country '%s' not found", tt.country)
+			}
+			if got := holidays.IsHoliday(tt.date); got != tt.wantHoliday {
+				t.Errorf("IsHoliday() = %v, want %v", got, tt.wantHoliday)
+			}
+		})
+	}
+}
+
+func TestParseHolidays_Invalid(t *testing.T) {
+	_, err := ParseHolidays(strings.NewReader("BEGIN:VEVENT\r\nDTSTART;VALUE=DATE:2024\r\nEND:VEVENT\r\n"))
+	if err == nil {
+		t.Errorf("expected error for invalid date")
+	}
+}
+
+const testCalendar = "BEGIN:VCALENDAR\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"DTSTART;VALUE=DATE:20241224\r\n" +
+	"DTEND;VALUE=DATE:20241227\r\n" +
+	"SUMMARY:Christmas\r\n" +
+	" holidays\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"DTSTART:20200701T000000Z\r\n" +
+	"RRULE:FREQ=YEARLY\r\n" +
+	"SUMMARY:Company day\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
@@ -0,0 +1,204 @@
+package businessday
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// built-in public holidays by ISO 3166 country or region code. only
+// nationwide holidays and those of the listed regions are included, one-off
+// holidays are not.
+
+type date struct {
+	year  int
+	month time.Month
+	day   int
+}
+
+func dateOf(t time.Time) date {
+	return date{year: t.Year(), month: t.Month(), day: t.Day()}
+}
+
+func (d date) time() time.Time {
+	return time.Date(d.year, d.month, d.day, 0, 0, 0, 0, time.UTC)
+}
+
+// holiday returns the dates of a holiday in the given year
+type holiday func(year int) []date
+
+type countryHolidays []holiday
+
+// IsHoliday returns whether the date is a holiday of the country. the
+// holidays of the next year are included as New Year's Day on a Saturday is
+// observed on December 31st.
+func (c countryHolidays) IsHoliday(t time.Time) bool {
+	day := dateOf(t)
+	for _, h := range c {
+		if slices.Contains(h(day.year), day) || slices.Contains(h(day.year+1), day) {
+			return true
+		}
+	}
+	return false
+}
+
+func fixed(month time.Month, day int) holiday {
+	return func(year int) []date {
+		return []date{{year: year, month: month, day: day}}
+	}
+}
+
+// easter returns a holiday relative to Easter Sunday
+func easter(offset int) holiday {
+	return func(year int) []date {
+		return []date{dateOf(easterSunday(year).AddDate(0, 0, offset))}
+	}
+}
+
+// nthWeekday returns the nth weekday of a month, counting from the end for negative n
+func nthWeekday(month time.Month, weekday time.Weekday, n int) holiday {
+	return func(year int) []date {
+		if n < 0 {
+			last := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC)
+			offset := (int(last.Weekday()) - int(weekday) + 7) % 7
+			return []date{dateOf(last.AddDate(0, 0, -offset+(n+1)*7))}
+		}
+		first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+		offset := (int(weekday) - int(first.Weekday()) + 7) % 7
+		return []date{dateOf(first.AddDate(0, 0, offset+(n-1)*7))}
+	}
+}
+
+// since limits a holiday to the years from its introduction
+func since(firstYear int, h holiday) holiday {
+	return func(year int) []date {
+		if year < firstYear {
+			return nil
+		}
+		return h(year)
+	}
+}
+
+// observed additionally returns the Friday before or the Monday after a
+// holiday which falls on a Saturday or Sunday
+func observed(h holiday) holiday {
+	return func(year int) []date {
+		result := make([]date, 0)
+		for _, day := range h(year) {
+			result = append(result, day)
+			switch day.time().Weekday() {
+			case time.Saturday:
+				result = append(result, dateOf(day.time().AddDate(0, 0, -1)))
+			case time.Sunday:
+				result = append(result, dateOf(day.time().AddDate(0, 0, 1)))
+			}
+		}
+		return result
+	}
+}
+
+// substitute moves a holiday on a weekend to the next weekday
+// which is not taken by one of the preceding holidays
+func substitute(holidays ...holiday) holiday {
+	return func(year int) []date {
+		result := make([]date, 0)
+		for _, h := range holidays {
+			for _, day := range h(year) {
+				current := day.time()
+				for current.Weekday() == time.Saturday || current.Weekday() == time.Sunday || slices.Contains(result, dateOf(current)) {
+					current = current.AddDate(0, 0, 1)
+				}
+				result = append(result, dateOf(current))
+			}
+		}
+		return result
+	}
+}
+
+// easterSunday uses the anonymous Gregorian algorithm
+func easterSunday(year int) time.Time {
+	a := year % 19
+	b := year / 100
+	c := year % 100
+	d := b / 4
+	e := b % 4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i := c / 4
+	k := c % 4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+}
+
+// kingsDay is on April 27th or on the 26th if the 27th is a Sunday
+func kingsDay(year int) []date {
+	day := time.Date(year, time.April, 27, 0, 0, 0, 0, time.UTC)
+	if day.Weekday() == time.Sunday {
+		day = day.AddDate(0, 0, -1)
+	}
+	return []date{dateOf(day)}
+}
+
+var (
+	newYear       = fixed(time.January, 1)
+	epiphany      = fixed(time.January, 6)
+	labourDay     = fixed(time.May, 1)
+	assumption    = fixed(time.August, 15)
+	reformation   = fixed(time.October, 31)
+	allSaints     = fixed(time.November, 1)
+	christmas     = fixed(time.December, 25)
+	boxingDay     = fixed(time.December, 26)
+	goodFriday    = easter(-2)
+	easterMonday  = easter(1)
+	ascension     = easter(39)
+	whitMonday    = easter(50)
+	corpusChristi = easter(60)
+)
+
+var germany = countryHolidays{
+	newYear, goodFriday, easterMonday, labourDay, ascension, whitMonday,
+	fixed(time.October, 3), christmas, boxingDay,
+}
+
+var countries = map[string]countryHolidays{
+	"DE":    germany,
+	"DE-BW": append(slices.Clone(germany), epiphany, corpusChristi, allSaints),
+	"DE-BY": append(slices.Clone(germany), epiphany, corpusChristi, allSaints),
+	"DE-BE": append(slices.Clone(germany), since(2019, fixed(time.March, 8))),
+	"DE-HE": append(slices.Clone(germany), corpusChristi),
+	"DE-HH": append(slices.Clone(germany), since(2018, reformation)),
+	"DE-NI": append(slices.Clone(germany), since(2018, reformation)),
+	"DE-NW": append(slices.Clone(germany), corpusChristi, allSaints),
+	"AT": {
+		newYear, epiphany, easterMonday, labourDay, ascension, whitMonday, corpusChristi,
+		assumption, fixed(time.October, 26), allSaints, fixed(time.December, 8), christmas, boxingDay,
+	},
+	"FR": {
+		newYear, easterMonday, labourDay, fixed(time.May, 8), ascension, whitMonday,
+		fixed(time.July, 14), assumption, allSaints, fixed(time.November, 11), christmas,
+	},
+	"NL": {
+		newYear, easterMonday, kingsDay, ascension, whitMonday, christmas, boxingDay,
+	},
+	"GB": {
+		substitute(newYear), goodFriday, easterMonday,
+		nthWeekday(time.May, time.Monday, 1), nthWeekday(time.May, time.Monday, -1),
+		nthWeekday(time.August, time.Monday, -1), substitute(christmas, boxingDay),
+	},
+	"US": {
+		observed(newYear), nthWeekday(time.January, time.Monday, 3), nthWeekday(time.February, time.Monday, 3),
+		nthWeekday(time.May, time.Monday, -1), since(2021, observed(fixed(time.June, 19))),
+		observed(fixed(time.July, 4)), nthWeekday(time.September, time.Monday, 1),
+		nthWeekday(time.October, time.Monday, 2), observed(fixed(time.November, 11)),
+		nthWeekday(time.November, time.Thursday, 4), observed(christmas),
+	},
+}
+
+func getCountryHolidays(code string) (countryHolidays, bool) {
+	result, ok := countries[strings.ToUpper(code)]
+	return result, ok
+}
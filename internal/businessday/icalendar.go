@@ -0,0 +1,112 @@
+package businessday
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// holiday files are iCalendar documents (RFC 5545) as exported by most
+// calendar applications. every day covered by an event is a holiday, events
+// with a yearly recurrence rule repeat on the same day each year.
+
+type calendarHolidays struct {
+	dates  map[date]bool
+	yearly map[date]bool
+}
+
+// IsHoliday returns whether the date is covered by an event of the holiday file
+func (c *calendarHolidays) IsHoliday(t time.Time) bool {
+	day := dateOf(t)
+	return c.dates[day] || c.yearly[date{month: day.month, day: day.day}]
+}
+
+// ParseHolidays reads the holidays of an iCalendar document
+func ParseHolidays(reader io.Reader) (Holidays, error) {
+	lines, err := unfoldLines(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &calendarHolidays{
+		dates:  make(map[date]bool),
+		yearly: make(map[date]bool),
+	}
+	var start, end time.Time
+	yearly := false
+	inEvent := false
+	for _, line := range lines {
+		name, value := splitProperty(line)
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			inEvent = true
+			start, end, yearly = time.Time{}, time.Time{}, false
+		case name == "END" && value == "VEVENT":
+			inEvent = false
+			if start.IsZero() {
+				return nil, fmt.Errorf("event without start in holiday file")
+			}
+			result.add(start, end, yearly)
+		case !inEvent:
+			continue
+		case name == "DTSTART":
+			if start, err = parseDate(value); err != nil {
+				return nil, err
+			}
+		case name == "DTEND":
+			if end, err = parseDate(value); err != nil {
+				return nil, err
+			}
+		case name == "RRULE":
+			yearly = strings.Contains(strings.ToUpper(value), "FREQ=YEARLY")
+		}
+	}
+	return result, nil
+}
+
+// add marks all days from start up to the exclusive end
+func (c *calendarHolidays) add(start time.Time, end time.Time, yearly bool) {
+	for day := start; day.Equal(start) || day.Before(end); day = day.AddDate(0, 0, 1) {
+		if yearly {
+			c.yearly[date{month: day.Month(), day: day.Day()}] = true
+		} else {
+			c.dates[dateOf(day)] = true
+		}
+	}
+}
+
+// unfoldLines joins continuation lines which start with a space or tab
+func unfoldLines(reader io.Reader) ([]string, error) {
+	result := make([]string, 0)
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(result) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			result[len(result)-1] += line[1:]
+			continue
+		}
+		result = append(result, line)
+	}
+	return result, scanner.Err()
+}
+
+// splitProperty returns the property name without parameters and its value
+func splitProperty(line string) (string, string) {
+	nameAndParams, value, _ := strings.Cut(line, ":")
+	name, _, _ := strings.Cut(nameAndParams, ";")
+	return strings.ToUpper(strings.TrimSpace(name)), strings.TrimSpace(value)
+}
+
+// parseDate reads the date of DATE and DATE-TIME values like 20240101 or 20240101T120000Z
+func parseDate(value string) (time.Time, error) {
+	if len(value) < 8 {
+		return time.Time{}, fmt.Errorf("invalid date '%s' in holiday file", value)
+	}
+	result, err := time.Parse("20060102", value[:8])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date '%s' in holiday file: %w", value, err)
+	}
+	return result, nil
+}
@@ -0,0 +1,77 @@
+package calendar
+
+import (
+	"strings"
+	"time"
+)
+
+// creates calendars as described in RFC 5545
+// https://datatracker.ietf.org/doc/html/rfc5545
+
+const (
+	productID     = "-//jo-hoe//whatsapp-reminder//EN"
+	timeLayout    = "20060102T150405Z"
+	maxLineLength = 75
+)
+
+type Event struct {
+	UID         string
+	Start       time.Time
+	Summary     string
+	Description string
+	URL         string
+}
+
+// CreateCalendar renders the events as an iCalendar document
+func CreateCalendar(events []Event, now time.Time) string {
+	var stringBuilder strings.Builder
+	writeLine(&stringBuilder, "BEGIN:VCALENDAR")
+	writeLine(&stringBuilder, "VERSION:2.0")
+	writeLine(&stringBuilder, "PRODID:"+productID)
+	writeLine(&stringBuilder, "CALSCALE:GREGORIAN")
+	writeLine(&stringBuilder, "METHOD:PUBLISH")
+	for _, event := range events {
+		writeLine(&stringBuilder, "BEGIN:VEVENT")
+		writeLine(&stringBuilder, "UID:"+escapeText(event.UID))
+		writeLine(&stringBuilder, "DTSTAMP:"+now.UTC().Format(timeLayout))
+		writeLine(&stringBuilder, "DTSTART:"+event.Start.UTC().Format(timeLayout))
+		writeLine(&stringBuilder, "DTEND:"+event.Start.UTC().Format(timeLayout))
+		writeLine(&stringBuilder, "SUMMARY:"+escapeText(event.Summary))
+		if event.Description != "" {
+			writeLine(&stringBuilder, "DESCRIPTION:"+escapeText(event.Description))
+		}
+		if event.URL != "" {
+			writeLine(&stringBuilder, "URL:"+event.URL)
+		}
+		writeLine(&stringBuilder, "END:VEVENT")
+	}
+	writeLine(&stringBuilder, "END:VCALENDAR")
+	return stringBuilder.String()
+}
+
+// writeLine folds content lines longer than 75 octets without splitting UTF-8 characters
+func writeLine(stringBuilder *strings.Builder, line string) {
+	length := 0
+	for _, r := range line {
+		runeLength := len(string(r))
+		if length+runeLength > maxLineLength {
+			stringBuilder.WriteString("\r\n ")
+			// the leading space of a continuation line counts towards its length
+			length = 1
+		}
+		stringBuilder.WriteRune(r)
+		length += runeLength
+	}
+	stringBuilder.WriteString("\r\n")
+}
+
+func escapeText(text string) string {
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	)
+	return replacer.Replace(text)
+}
@@ -0,0 +1,60 @@
+package calendar
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCreateCalendar(t *testing.T) {
+	now := time.Date(2022, 07, 20, 13, 13, 13, 0, time.UTC)
+	location, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Errorf("found error %+v", err)
+	}
+	events := []Event{
+		{
+			UID:         "1@whatsapp-reminder",
+			Start:       time.Date(2022, 07, 22, 15, 15, 15, 0, location),
+			Summary:     "Hello, World; 1",
+			Description: "line 1\nline 2",
+			URL:         "https://wa.me/15551234567?text=Hello",
+		},
+	}
+
+	actual := CreateCalendar(events, now)
+
+	expectedLines := []string{
+		"BEGIN:VCALENDAR\r\n",
+		"BEGIN:VEVENT\r\n",
+		"DTSTAMP:20220720T131313Z\r\n",
+		"DTSTART:20220722T131515Z\r\n",
+		"SUMMARY:Hello\\, World\\; 1\r\n",
+		"DESCRIPTION:line 1\\nline 2\r\n",
+		"URL:https://wa.me/15551234567?text=Hello\r\n",
+		"END:VCALENDAR\r\n",
+	}
+	for _, expected := range expectedLines {
+		if !strings.Contains(actual, expected) {
+			t.Errorf("expected %q in calendar:\n%s", expected, actual)
+		}
+	}
+}
+
+func TestCreateCalendar_LineFolding(t *testing.T) {
+	events := []Event{
+		{UID: "1", Summary: strings.Repeat("😉", 40)},
+	}
+
+	actual := CreateCalendar(events, time.Now())
+
+	for _, line := range strings.Split(actual, "\r\n") {
+		if len(line) > maxLineLength {
+			t.Errorf("line with %d octets exceeds limit: %q", len(line), line)
+		}
+	}
+	unfolded := strings.ReplaceAll(actual, "\r\n ", "")
+	if !strings.Contains(unfolded, "SUMMARY:"+strings.Repeat("😉", 40)+"\r\n") {
+		t.Errorf("unfolded calendar does not contain summary:\n%s", unfolded)
+	}
+}
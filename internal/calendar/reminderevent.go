@@ -0,0 +1,38 @@
+package calendar
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+
+	"github.com/jo-hoe/whatsapp-reminder/internal/dto"
+	"github.com/jo-hoe/whatsapp-reminder/internal/whatsapp"
+)
+
+const defaultSummary = "WhatsApp Reminder"
+
+// NewReminderEvent creates an event at the due time of the reminder
+// linking to the WhatsApp message
+func NewReminderEvent(messageConfig dto.WhatsappReminderConfig, linkStyle whatsapp.LinkStyle) Event {
+	link := whatsapp.CreateLink(linkStyle, messageConfig.PhoneNumber, messageConfig.MessageText)
+
+	summary := messageConfig.MessageText
+	if summary == "" {
+		summary = defaultSummary
+	}
+
+	return Event{
+		UID:         createUID(messageConfig),
+		Start:       messageConfig.DueTime,
+		Summary:     summary,
+		Description: messageConfig.MessageText + "\n\n" + link,
+		URL:         link,
+	}
+}
+
+// createUID derives a stable identifier so that re-imported events update instead of duplicate
+func createUID(messageConfig dto.WhatsappReminderConfig) string {
+	hash := sha256.Sum256([]byte(strconv.FormatInt(messageConfig.DueTime.Unix(), 10) + "\x00" +
+		messageConfig.PhoneNumber + "\x00" + messageConfig.MessageText))
+	return hex.EncodeToString(hash[:16]) + "@whatsapp-reminder"
+}
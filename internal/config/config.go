@@ -1,16 +1,33 @@
 package config
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log"
+	"net/mail"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/jo-hoe/whatsapp-reminder/internal/action"
+	"github.com/jo-hoe/whatsapp-reminder/internal/businessday"
+	"github.com/jo-hoe/whatsapp-reminder/internal/duration"
+	"github.com/jo-hoe/whatsapp-reminder/internal/googleauth"
+	"github.com/jo-hoe/whatsapp-reminder/internal/quiethours"
+	"github.com/jo-hoe/whatsapp-reminder/internal/timeformat"
+	"github.com/jo-hoe/whatsapp-reminder/internal/whatsapp"
 	"gopkg.in/yaml.v2"
 )
 
 // Config represents the application configuration
 type Config struct {
+	// Name is the name of the source this configuration was created for,
+	// empty without sources
+	Name string `yaml:"-"`
+
 	// Google Sheets configuration
 	GoogleSheets GoogleSheetsConfig `yaml:"googleSheets"`
 
@@ -22,12 +39,94 @@ type Config struct {
 
 	// Application configuration
 	App AppConfig `yaml:"app"`
+
+	// Contacts configuration
+	Contacts ContactsConfig `yaml:"contacts"`
+
+	// Actions configuration
+	Actions ActionsConfig `yaml:"actions"`
+
+	// Backup configuration
+	Backup BackupConfig `yaml:"backup"`
+
+	// Archive configuration
+	Archive ArchiveConfig `yaml:"archive"`
+
+	// Sources are processed independently, each overriding the settings above.
+	// If empty, googleSheets is the only source.
+	Sources []SourceConfig `yaml:"sources"`
+}
+
+// SourceConfig is a sheet processed with its own recipients and time zone.
+// Empty fields fall back to the global settings. Email is the only delivery
+// channel, so sources differ in their recipients and link style instead of
+// selecting a channel.
+type SourceConfig struct {
+	// Name identifies the source in logs and command line flags
+	Name           string `yaml:"name"`
+	SpreadsheetID  string `yaml:"spreadsheetId"`
+	SheetName      string `yaml:"sheetName"`
+	ErrorSheetName string `yaml:"errorSheetName"`
+	// ResponsesSheetName overrides googleSheets.forms.responsesSheetName
+	ResponsesSheetName string `yaml:"responsesSheetName"`
+	// TimeLocation overrides app.timeLocation
+	TimeLocation string `yaml:"timeLocation"`
+	// Email overrides the recipients and link style of email
+	Email SourceEmailConfig `yaml:"email"`
+}
+
+type SourceEmailConfig struct {
+	To        []string `yaml:"to"`
+	Cc        []string `yaml:"cc"`
+	Bcc       []string `yaml:"bcc"`
+	LinkStyle string   `yaml:"linkStyle"`
 }
 
 type GoogleSheetsConfig struct {
 	SpreadsheetID      string `yaml:"spreadsheetId"`
 	SheetName          string `yaml:"sheetName"`
 	ServiceAccountFile string `yaml:"serviceAccountFile"`
+	// Auth selects how to authenticate at Google, with serviceAccountFile by default
+	Auth GoogleAuthConfig `yaml:"auth"`
+	// ColumnAliases are additional headers by column name, e.g. {"Send Date": ["Datum"]}
+	ColumnAliases map[string][]string `yaml:"columnAliases"`
+	// ErrorSheetName is an existing tab receiving rows which could not be read,
+	// if empty they are kept in the sheet
+	ErrorSheetName string `yaml:"errorSheetName"`
+	// WriteMode is "overwrite" to replace the sheet or "merge" to read it again
+	// before writing and keep rows edited by someone else in the meantime
+	WriteMode string `yaml:"writeMode"`
+	// Forms configures the sheet to be filled by a Google Form
+	Forms FormsConfig `yaml:"forms"`
+}
+
+// GoogleAuthConfig selects the credentials used to access the spreadsheet
+type GoogleAuthConfig struct {
+	// Type is "serviceAccount", "adc" for an application default credentials
+	// file or "oauth" for user credentials
+	Type string `yaml:"type"`
+	// ServiceAccountBase64Env names an environment variable with the base64
+	// encoded service account key, used instead of serviceAccountFile if set
+	ServiceAccountBase64Env string `yaml:"serviceAccountBase64Env"`
+	// ClientSecretFile is the OAuth client of type desktop app
+	ClientSecretFile string `yaml:"clientSecretFile"`
+	// TokenCacheFile keeps the refresh token after the first authorization
+	TokenCacheFile string `yaml:"tokenCacheFile"`
+}
+
+// FormsConfig handles sheets filled by Google Forms
+type FormsConfig struct {
+	// Enabled fills an empty timestamp with the time the row was first read
+	Enabled bool `yaml:"enabled"`
+	// Locale of the spreadsheet, e.g. "en-US", defines the layout of dates
+	// written by Google Forms
+	Locale string `yaml:"locale"`
+	// ResponsesSheetName is a read-only tab with form responses which are
+	// imported into googleSheets.sheetName
+	ResponsesSheetName string `yaml:"responsesSheetName"`
+	// ImportedSheetName is an existing tab listing the imported responses,
+	// it can be shared by sources importing different responses tabs
+	ImportedSheetName string `yaml:"importedSheetName"`
 }
 
 type SMTPAuthConfig struct {
@@ -41,9 +140,75 @@ type EmailConfig struct {
 	Port     int            `yaml:"port"`
 	From     string         `yaml:"from"`
 	To       []string       `yaml:"to"`
+	Cc       []string       `yaml:"cc"`
+	Bcc      []string       `yaml:"bcc"`
+	ReplyTo  string         `yaml:"replyTo"`
 	Auth     SMTPAuthConfig `yaml:"auth"`
 	StartTLS bool           `yaml:"startTLS"`
 	Timeout  time.Duration  `yaml:"timeout"`
+	// CombineRecipients sends a single mail addressed to all recipients
+	// instead of a separate mail per recipient
+	CombineRecipients bool `yaml:"combineRecipients"`
+	// QRCodes embeds a scannable QR code of the WhatsApp link for each reminder
+	QRCodes bool `yaml:"qrCodes"`
+	// ICSAttachment attaches an iCalendar file with an event for each reminder
+	ICSAttachment bool `yaml:"icsAttachment"`
+	// LinkStyle selects how WhatsApp links are created (wa.me, api, app or business)
+	LinkStyle string `yaml:"linkStyle"`
+	// EscalationTo are notified about reminders which were not acknowledged after all resends
+	EscalationTo []string `yaml:"escalationTo"`
+	// QuietHours overrides app.quietHours for email delivery
+	QuietHours *QuietHoursConfig `yaml:"quietHours"`
+}
+
+// QuietHoursConfig defines when due reminders are held, e.g. from 22:00 to 07:00
+// in app.timeLocation
+type QuietHoursConfig struct {
+	Start        string `yaml:"start"`
+	End          string `yaml:"end"`
+	WeekdaysOnly bool   `yaml:"weekdaysOnly"`
+}
+
+// ContactsConfig configures an optional contacts source to resolve
+// names and aliases in the phone number column
+type ContactsConfig struct {
+	// SheetName reads contacts from another tab of the spreadsheet
+	SheetName string `yaml:"sheetName"`
+	// File reads contacts from a CSV or vCard (.vcf) file
+	File string `yaml:"file"`
+}
+
+// ActionsConfig enables links in reminder emails to mark
+// reminders as done or to snooze them
+type ActionsConfig struct {
+	// BaseURL is the public URL of the action endpoint, e.g. "https://reminder.example.com"
+	BaseURL string `yaml:"baseUrl"`
+	// Secret signs the action links
+	Secret string `yaml:"secret"`
+	// ListenAddress is the address the action endpoint listens on
+	ListenAddress string `yaml:"listenAddress"`
+	// Validity is how long action links can be used, e.g. "7d"
+	Validity string `yaml:"validity"`
+}
+
+// BackupConfig saves a snapshot of the sheet before each write
+// to a local directory or another tab of the spreadsheet
+type BackupConfig struct {
+	// Directory keeps each snapshot as CSV file
+	Directory string `yaml:"directory"`
+	// SheetName is an existing tab keeping all snapshots
+	SheetName string `yaml:"sheetName"`
+	// Keep is the number of snapshots to keep, 10 by default, all are kept if negative
+	Keep int `yaml:"keep"`
+}
+
+// ArchiveConfig keeps entries after the retention time instead of deleting
+// them, in another tab of the spreadsheet or a local CSV file
+type ArchiveConfig struct {
+	// SheetName is an existing tab receiving the archived entries
+	SheetName string `yaml:"sheetName"`
+	// File is a CSV file receiving the archived entries, created if missing
+	File string `yaml:"file"`
 }
 
 type ScheduleConfig struct {
@@ -55,6 +220,50 @@ type AppConfig struct {
 	TimeLocation  string `yaml:"timeLocation"`
 	RetentionTime string `yaml:"retentionTime"`
 	LogLevel      string `yaml:"logLevel"`
+	// DefaultCountryCode is prepended to phone numbers given in national format, e.g. "49"
+	DefaultCountryCode string `yaml:"defaultCountryCode"`
+	// LeadTimes are comma separated durations before the due time to send advance notices, e.g. "7d,1d"
+	LeadTimes string `yaml:"leadTimes"`
+	// QuietHours applies to email, the only delivery channel, unless
+	// email.quietHours overrides it
+	QuietHours QuietHoursConfig `yaml:"quietHours"`
+	// BusinessDayRule rolls due times forward to business days unless a row defines
+	// its own rule: "none", "weekends", "holidays" or a country code like "DE" or "DE-BY"
+	BusinessDayRule string `yaml:"businessDayRule"`
+	// HolidayFile is an iCalendar file with the holidays of the "holidays" rule
+	HolidayFile string `yaml:"holidayFile"`
+	// MaxLateness skips reminders which are overdue by more than this duration, e.g. "1d"
+	MaxLateness string `yaml:"maxLateness"`
+	// CatchUpDigest sends a summary of skipped reminders
+	CatchUpDigest bool `yaml:"catchUpDigest"`
+	// Escalation resends reminders which were not acknowledged
+	Escalation EscalationConfig `yaml:"escalation"`
+	// DateTimeFormat defines how dates and times are read from and written to the sheet
+	DateTimeFormat DateTimeFormatConfig `yaml:"dateTimeFormat"`
+}
+
+// DateTimeFormatConfig uses Go reference layouts. ISO 8601 and spreadsheet
+// serial numbers are always accepted when reading.
+type DateTimeFormatConfig struct {
+	// Date is the layout to write dates, e.g. "02/01/2006" or "2006-01-02"
+	Date string `yaml:"date"`
+	// Time is the layout to write clock times, e.g. "15:04:05" or "3:04 PM"
+	Time string `yaml:"time"`
+	// InputDates are additional layouts accepted when reading dates, e.g. "01/02/2006"
+	InputDates []string `yaml:"inputDates"`
+	// InputTimes are additional layouts accepted when reading clock times
+	InputTimes []string `yaml:"inputTimes"`
+}
+
+// EscalationConfig resends reminders which are not acknowledged within a delay
+// and finally notifies email.escalationTo
+type EscalationConfig struct {
+	// After is the delay after sending until a reminder is sent again, e.g. "4h", empty disables escalation
+	After string `yaml:"after"`
+	// MaxResends is the number of resends before the escalation recipients are notified
+	MaxResends int `yaml:"maxResends"`
+	// AllReminders escalates reminders without a value in the "Escalate" column
+	AllReminders bool `yaml:"allReminders"`
 }
 
 // LoadConfig loads configuration from a YAML file
@@ -74,9 +283,23 @@ func LoadConfig(configPath string) (*Config, error) {
 	}
 
 	var config Config
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	// unknown keys are reported with their line, e.g. misspelled or outdated keys.
+	// decoding continues after them, so they are reported with all other problems.
+	var decodeErrs []error
+	if err := yaml.UnmarshalStrict(data, &config); err != nil {
+		var typeErr *yaml.TypeError
+		if !errors.As(err, &typeErr) {
+			return nil, fmt.Errorf("failed to parse config file %s: %w", configPath, err)
+		}
+		for _, message := range typeErr.Errors {
+			decodeErrs = append(decodeErrs, errors.New(message))
+		}
+	}
+
+	if err := applyEnv(&config, os.LookupEnv); err != nil {
+		return nil, fmt.Errorf("failed to apply environment variables: %w", err)
 	}
+	decodeErrs = append(decodeErrs, unknownEnv(os.Environ())...)
 
 	// Set defaults
 	if config.Schedule.Interval == "" {
@@ -97,60 +320,411 @@ func LoadConfig(configPath string) (*Config, error) {
 	if config.Email.Timeout == 0 {
 		config.Email.Timeout = 30 * time.Second
 	}
+	if config.Actions.ListenAddress == "" {
+		config.Actions.ListenAddress = ":8080"
+	}
+	if config.Actions.Validity == "" {
+		config.Actions.Validity = "7d"
+	}
+	if config.Backup.Keep == 0 {
+		config.Backup.Keep = 10
+	}
 
 	// Validate required fields
-	if err := config.validate(); err != nil {
+	if err := config.validate(decodeErrs...); err != nil {
 		return nil, fmt.Errorf("config validation failed: %w", err)
 	}
 
 	return &config, nil
 }
 
-// validate checks that all required configuration fields are present
-func (c *Config) validate() error {
+// validate checks that all required configuration fields are present and
+// reports all problems at once, following the given decoding problems
+func (c *Config) validate(decodeErrs ...error) error {
+	errs := decodeErrs
+	if len(c.Sources) > 0 {
+		errs = append(errs, c.validateSources()...)
+	} else {
+		errs = append(errs, c.validateSource()...)
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return validationErrors(errs)
+}
+
+// validateSources checks each source with the settings it falls back to
+func (c *Config) validateSources() []error {
+	var errs []error
+	names := make(map[string]bool)
+	sheets := make(map[string]string)
+	spreadsheets := make(map[string]string)
+	// problems of the shared settings are reported once, problems which only
+	// occur with the settings of a source are reported for each source
+	shared := make(map[string]bool)
+	for _, err := range c.validateSource() {
+		shared[err.Error()] = true
+	}
+	reported := make(map[string]bool)
+	for i, source := range c.Sources {
+		if source.Name == "" {
+			errs = append(errs, fmt.Errorf("sources[%d].name is required", i))
+			continue
+		}
+		if strings.ContainsAny(source.Name, `/\`) {
+			errs = append(errs, fmt.Errorf("sources[%d].name must not contain path separators", i))
+			continue
+		}
+		if names[source.Name] {
+			errs = append(errs, fmt.Errorf("sources[%d].name '%s' is not unique", i, source.Name))
+			continue
+		}
+		names[source.Name] = true
+
+		sourceConfig := c.source(source)
+		for _, err := range sourceConfig.validateSource() {
+			if !shared[err.Error()] {
+				errs = append(errs, fmt.Errorf("source '%s': %w", source.Name, err))
+			} else if !reported[err.Error()] {
+				reported[err.Error()] = true
+				errs = append(errs, err)
+			}
+		}
+
+		sheet := sourceConfig.GoogleSheets.SpreadsheetID + "/" + sourceConfig.GoogleSheets.SheetName
+		if other, ok := sheets[sheet]; ok {
+			errs = append(errs, fmt.Errorf("sources '%s' and '%s' use the same sheet", other, source.Name))
+		}
+		sheets[sheet] = source.Name
+
+		// snapshots and archived rows of sources in the same spreadsheet would be mixed in one tab
+		if other, ok := spreadsheets[sourceConfig.GoogleSheets.SpreadsheetID]; ok {
+			if c.Backup.SheetName != "" {
+				errs = append(errs, fmt.Errorf("sources '%s' and '%s' share a spreadsheet, backup.sheetName cannot be used, use backup.directory instead", other, source.Name))
+			}
+			if c.Archive.SheetName != "" {
+				errs = append(errs, fmt.Errorf("sources '%s' and '%s' share a spreadsheet, archive.sheetName cannot be used, use archive.file instead", other, source.Name))
+			}
+		} else {
+			spreadsheets[sourceConfig.GoogleSheets.SpreadsheetID] = source.Name
+		}
+	}
+	return errs
+}
+
+// validateSource checks the settings of a single source
+func (c *Config) validateSource() []error {
+	var errs []error
 	if c.GoogleSheets.SpreadsheetID == "" {
-		return fmt.Errorf("googleSheets.spreadsheetId is required")
+		errs = append(errs, fmt.Errorf("googleSheets.spreadsheetId is required"))
 	}
 	if c.GoogleSheets.SheetName == "" {
-		return fmt.Errorf("googleSheets.sheetName is required")
+		errs = append(errs, fmt.Errorf("googleSheets.sheetName is required"))
 	}
-	if c.GoogleSheets.ServiceAccountFile == "" {
-		return fmt.Errorf("googleSheets.serviceAccountFile is required")
+	authType, err := googleauth.ParseType(c.GoogleSheets.Auth.Type)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("invalid googleSheets.auth.type: %w", err))
+	}
+	switch authType {
+	case googleauth.ServiceAccount:
+		if c.GoogleSheets.ServiceAccountFile == "" && c.GoogleSheets.Auth.ServiceAccountBase64Env == "" {
+			errs = append(errs, fmt.Errorf("googleSheets.serviceAccountFile or googleSheets.auth.serviceAccountBase64Env is required"))
+		}
+	case googleauth.OAuthUser:
+		if c.GoogleSheets.Auth.ClientSecretFile == "" {
+			errs = append(errs, fmt.Errorf("googleSheets.auth.clientSecretFile is required for oauth"))
+		}
+		if c.GoogleSheets.Auth.TokenCacheFile == "" {
+			errs = append(errs, fmt.Errorf("googleSheets.auth.tokenCacheFile is required for oauth"))
+		}
+	}
+	if c.GoogleSheets.ErrorSheetName != "" && c.GoogleSheets.ErrorSheetName == c.GoogleSheets.SheetName {
+		errs = append(errs, fmt.Errorf("googleSheets.errorSheetName must differ from googleSheets.sheetName"))
+	}
+	if c.GoogleSheets.Forms.Locale != "" {
+		if _, err := timeformat.LocaleDateLayout(c.GoogleSheets.Forms.Locale); err != nil {
+			errs = append(errs, fmt.Errorf("invalid googleSheets.forms.locale: %w", err))
+		}
+	}
+	if c.GoogleSheets.Forms.ResponsesSheetName != "" {
+		if c.GoogleSheets.Forms.ResponsesSheetName == c.GoogleSheets.SheetName {
+			errs = append(errs, fmt.Errorf("googleSheets.forms.responsesSheetName must differ from googleSheets.sheetName"))
+		}
+		if c.GoogleSheets.Forms.ImportedSheetName == "" {
+			errs = append(errs, fmt.Errorf("googleSheets.forms.importedSheetName is required to import responses"))
+		} else if c.GoogleSheets.Forms.ImportedSheetName == c.GoogleSheets.SheetName || c.GoogleSheets.Forms.ImportedSheetName == c.GoogleSheets.Forms.ResponsesSheetName {
+			errs = append(errs, fmt.Errorf("googleSheets.forms.importedSheetName must differ from googleSheets.sheetName and googleSheets.forms.responsesSheetName"))
+		}
 	}
 	if c.Email.Host == "" {
-		return fmt.Errorf("email.host is required")
+		errs = append(errs, fmt.Errorf("email.host is required"))
 	}
 	if c.Email.Port == 0 {
-		return fmt.Errorf("email.port is required")
+		errs = append(errs, fmt.Errorf("email.port is required"))
 	}
 	if c.Email.From == "" {
-		return fmt.Errorf("email.from is required")
+		errs = append(errs, fmt.Errorf("email.from is required"))
 	}
 	if len(c.Email.To) == 0 {
-		return fmt.Errorf("email.to must have at least one recipient")
+		errs = append(errs, fmt.Errorf("email.to must have at least one recipient"))
 	}
+	errs = append(errs, validateAddresses("email.from", c.Email.From)...)
+	errs = append(errs, validateAddresses("email.to", c.Email.To...)...)
+	errs = append(errs, validateAddresses("email.cc", c.Email.Cc...)...)
+	errs = append(errs, validateAddresses("email.bcc", c.Email.Bcc...)...)
+	errs = append(errs, validateAddresses("email.escalationTo", c.Email.EscalationTo...)...)
+	errs = append(errs, validateAddresses("email.replyTo", c.Email.ReplyTo)...)
 
 	// Validate duration formats
 	if _, err := time.ParseDuration(c.Schedule.Interval); err != nil {
-		return fmt.Errorf("invalid schedule.interval: %w", err)
+		errs = append(errs, fmt.Errorf("invalid schedule.interval: %w", err))
 	}
 	if _, err := time.ParseDuration(c.App.RetentionTime); err != nil {
-		return fmt.Errorf("invalid app.retentionTime: %w", err)
+		errs = append(errs, fmt.Errorf("invalid app.retentionTime: %w", err))
 	}
 
 	// Validate timezone
 	if _, err := time.LoadLocation(c.App.TimeLocation); err != nil {
-		return fmt.Errorf("invalid app.timeLocation: %w", err)
+		errs = append(errs, fmt.Errorf("invalid app.timeLocation: %w", err))
+	}
+
+	if _, err := whatsapp.ParseLinkStyle(c.Email.LinkStyle); err != nil {
+		errs = append(errs, fmt.Errorf("invalid email.linkStyle: %w", err))
+	}
+
+	if _, err := whatsapp.NormalizeCountryCode(c.App.DefaultCountryCode); err != nil {
+		errs = append(errs, fmt.Errorf("invalid app.defaultCountryCode: %w", err))
+	}
+
+	if _, err := duration.ParseList(c.App.LeadTimes); err != nil {
+		errs = append(errs, fmt.Errorf("invalid app.leadTimes: %w", err))
+	}
+
+	if _, err := c.GetMaxLateness(); err != nil {
+		errs = append(errs, fmt.Errorf("invalid app.maxLateness: %w", err))
 	}
 
-	return nil
+	if _, err := c.GetEscalationDelay(); err != nil {
+		errs = append(errs, fmt.Errorf("invalid app.escalation.after: %w", err))
+	} else if c.App.Escalation.After != "" && len(c.Email.EscalationTo) == 0 {
+		errs = append(errs, fmt.Errorf("email.escalationTo is required for app.escalation.after"))
+	}
+	if c.App.Escalation.MaxResends < 0 {
+		errs = append(errs, fmt.Errorf("app.escalation.maxResends must not be negative"))
+	}
+
+	if _, err := c.GetTimeFormat(); err != nil {
+		errs = append(errs, fmt.Errorf("invalid app.dateTimeFormat: %w", err))
+	}
+
+	if _, err := c.GetQuietHours(time.UTC); err != nil {
+		errs = append(errs, fmt.Errorf("invalid quiet hours: %w", err))
+	}
+
+	if err := businessday.Validate(c.App.BusinessDayRule, c.App.HolidayFile != ""); err != nil {
+		errs = append(errs, fmt.Errorf("invalid app.businessDayRule: %w", err))
+	}
+
+	if _, err := duration.Parse(c.Actions.Validity); err != nil {
+		errs = append(errs, fmt.Errorf("invalid actions.validity: %w", err))
+	}
+	if c.Actions.BaseURL != "" {
+		if baseURL, err := url.Parse(c.Actions.BaseURL); err != nil || (baseURL.Scheme != "http" && baseURL.Scheme != "https") {
+			errs = append(errs, fmt.Errorf("actions.baseUrl must be an absolute http(s) URL"))
+		}
+		if len(c.Actions.Secret) < action.MinSecretLength {
+			errs = append(errs, fmt.Errorf("actions.secret must have at least %d characters", action.MinSecretLength))
+		}
+	}
+
+	if c.Backup.Directory != "" && c.Backup.SheetName != "" {
+		errs = append(errs, fmt.Errorf("only one of backup.directory and backup.sheetName can be set"))
+	}
+	if c.Backup.SheetName != "" && c.Backup.SheetName == c.GoogleSheets.SheetName {
+		errs = append(errs, fmt.Errorf("backup.sheetName must differ from googleSheets.sheetName"))
+	}
+
+	if c.Archive.SheetName != "" && c.Archive.File != "" {
+		errs = append(errs, fmt.Errorf("only one of archive.sheetName and archive.file can be set"))
+	}
+	if c.Archive.SheetName != "" && c.Archive.SheetName == c.GoogleSheets.SheetName {
+		errs = append(errs, fmt.Errorf("archive.sheetName must differ from googleSheets.sheetName"))
+	}
+
+	if c.Contacts.SheetName != "" && c.Contacts.File != "" {
+		errs = append(errs, fmt.Errorf("only one of contacts.sheetName and contacts.file can be set"))
+	}
+
+	return errs
+}
+
+// validateAddresses checks the syntax of the mail addresses, empty values are skipped
+func validateAddresses(field string, addresses ...string) []error {
+	var errs []error
+	for _, address := range addresses {
+		if address == "" {
+			continue
+		}
+		if _, err := mail.ParseAddress(address); err != nil {
+			errs = append(errs, fmt.Errorf("invalid mail address '%s' in %s: %w", address, field, err))
+		}
+	}
+	return errs
 }
 
-// GetServiceAccountSecret returns the service account secret from file
-func (c *Config) GetServiceAccountSecret() ([]byte, error) {
-	data, err := os.ReadFile(c.GoogleSheets.ServiceAccountFile)
+// validationErrors lists all problems of a configuration
+type validationErrors []error
+
+func (errs validationErrors) Error() string {
+	lines := make([]string, 0, len(errs))
+	for _, err := range errs {
+		lines = append(lines, "  - "+err.Error())
+	}
+	return fmt.Sprintf("%d problem(s):\n%s", len(errs), strings.Join(lines, "\n"))
+}
+
+func (errs validationErrors) Unwrap() []error {
+	return errs
+}
+
+// GetSources returns the configuration of each source with the global
+// settings it does not override. Without sources, it only returns the
+// configuration itself.
+func (c *Config) GetSources() []Config {
+	if len(c.Sources) == 0 {
+		return []Config{*c}
+	}
+	result := make([]Config, 0, len(c.Sources))
+	for _, source := range c.Sources {
+		result = append(result, c.source(source))
+	}
+	return result
+}
+
+func (c *Config) source(source SourceConfig) Config {
+	result := *c
+	result.Sources = nil
+	result.Name = source.Name
+	if source.SpreadsheetID != "" {
+		result.GoogleSheets.SpreadsheetID = source.SpreadsheetID
+	}
+	result.GoogleSheets.SheetName = source.SheetName
+	if source.ErrorSheetName != "" {
+		result.GoogleSheets.ErrorSheetName = source.ErrorSheetName
+	}
+	if source.ResponsesSheetName != "" {
+		result.GoogleSheets.Forms.ResponsesSheetName = source.ResponsesSheetName
+	}
+	if source.TimeLocation != "" {
+		result.App.TimeLocation = source.TimeLocation
+	}
+	if len(source.Email.To) > 0 {
+		result.Email.To = source.Email.To
+	}
+	if len(source.Email.Cc) > 0 {
+		result.Email.Cc = source.Email.Cc
+	}
+	if len(source.Email.Bcc) > 0 {
+		result.Email.Bcc = source.Email.Bcc
+	}
+	if source.Email.LinkStyle != "" {
+		result.Email.LinkStyle = source.Email.LinkStyle
+	}
+	// each source keeps its snapshots in its own directory
+	if result.Backup.Directory != "" {
+		result.Backup.Directory = filepath.Join(result.Backup.Directory, source.Name)
+	}
+	return result
+}
+
+// GetGoogleCredentials returns the credentials to access the spreadsheet as
+// JSON document. For oauth, the user is asked to authorize access in the
+// browser if no valid token is cached and interactive is set, otherwise an
+// error is returned.
+func (c *Config) GetGoogleCredentials(ctx context.Context, interactive bool) ([]byte, error) {
+	authType, err := googleauth.ParseType(c.GoogleSheets.Auth.Type)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read service account file %s: %w", c.GoogleSheets.ServiceAccountFile, err)
+		return nil, err
+	}
+	switch authType {
+	case googleauth.ApplicationDefault:
+		return googleauth.ApplicationDefaultCredentials()
+	case googleauth.OAuthUser:
+		var open func(authURL string)
+		if interactive {
+			open = func(authURL string) {
+				log.Printf("open this URL in a browser to authorize access to Google Sheets:\n%s", authURL)
+			}
+		}
+		return googleauth.OAuthUserCredentials(ctx, c.GoogleSheets.Auth.ClientSecretFile, c.GoogleSheets.Auth.TokenCacheFile, open)
+	}
+	return googleauth.ServiceAccountKey(c.GoogleSheets.ServiceAccountFile, c.GoogleSheets.Auth.ServiceAccountBase64Env)
+}
+
+// GetQuietHours returns the quiet hours for email delivery which fall back to
+// the global quiet hours. Email is the only delivery channel, another channel
+// needs its own override instead of using the quiet hours of email.
+func (c *Config) GetQuietHours(location *time.Location) (quiethours.QuietHours, error) {
+	quietHours := c.App.QuietHours
+	if c.Email.QuietHours != nil {
+		quietHours = *c.Email.QuietHours
+	}
+	return quiethours.Parse(quietHours.Start, quietHours.End, quietHours.WeekdaysOnly, location)
+}
+
+// GetMaxLateness returns zero if stale reminders are never skipped
+func (c *Config) GetMaxLateness() (time.Duration, error) {
+	if c.App.MaxLateness == "" {
+		return 0, nil
+	}
+	return duration.Parse(c.App.MaxLateness)
+}
+
+// GetEscalationDelay returns zero if escalation is disabled
+func (c *Config) GetEscalationDelay() (time.Duration, error) {
+	if c.App.Escalation.After == "" {
+		return 0, nil
+	}
+	return duration.Parse(c.App.Escalation.After)
+}
+
+// GetTimeFormat returns the format of dates and times in the sheet. The date
+// layout of googleSheets.forms.locale is used if app.dateTimeFormat.date is
+// not set and accepted when reading otherwise.
+func (c *Config) GetTimeFormat() (timeformat.Format, error) {
+	format := c.App.DateTimeFormat
+	dateLayout, inputDates := format.Date, format.InputDates
+	if c.GoogleSheets.Forms.Locale != "" {
+		localeLayout, err := timeformat.LocaleDateLayout(c.GoogleSheets.Forms.Locale)
+		if err != nil {
+			return timeformat.Format{}, err
+		}
+		if dateLayout == "" {
+			dateLayout = localeLayout
+		} else {
+			inputDates = append([]string{localeLayout}, inputDates...)
+		}
+	}
+	return timeformat.New(dateLayout, format.Time, inputDates, format.InputTimes)
+}
+
+// GetActionValidity returns how long action links can be used
+func (c *Config) GetActionValidity() (time.Duration, error) {
+	return duration.Parse(c.Actions.Validity)
+}
+
+// GetBusinessDayRules returns the business day rules with the holidays of the holiday file
+func (c *Config) GetBusinessDayRules() (businessday.Rules, error) {
+	var holidays businessday.Holidays
+	if c.App.HolidayFile != "" {
+		file, err := os.Open(filepath.Clean(c.App.HolidayFile))
+		if err != nil {
+			return businessday.Rules{}, fmt.Errorf("failed to read holiday file %s: %w", c.App.HolidayFile, err)
+		}
+		defer func() { _ = file.Close() }()
+		if holidays, err = businessday.ParseHolidays(file); err != nil {
+			return businessday.Rules{}, fmt.Errorf("failed to parse holiday file %s: %w", c.App.HolidayFile, err)
+		}
 	}
-	return data, nil
+	return businessday.NewRules(c.App.BusinessDayRule, holidays)
 }
@@ -0,0 +1,392 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfig_Example(t *testing.T) {
+	if _, err := LoadConfig("../../config.yaml.example"); err != nil {
+		t.Errorf("example config could not be loaded: %v", err)
+	}
+}
+
+func TestLoadConfig_UnknownKeys(t *testing.T) {
+	path := writeConfig(t, `googleSheets:
+  spreadsheetId: "id"
+  sheetName: "Reminders"
+  serviceAccountFile: "key.json"
+email:
+  serviceUrl: "http://localhost:80"
+  host: "smtp.example.com"
+  from: "reminder@example.com"
+  to: ["you@example.com"]
+  originAddress: "reminder@example.com"
+`)
+
+	_, err := LoadConfig(path)
+	if err == nil {
+		t.Fatal("expected error for unknown keys")
+	}
+	for _, expected := range []string{"line 6: field serviceUrl not found", "line 10: field originAddress not found"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected '%s' in error:\n%v", expected, err)
+		}
+	}
+}
+
+func TestLoadConfig_AllValidationErrors(t *testing.T) {
+	path := writeConfig(t, `googleSheets:
+  sheetName: "Reminders"
+  serviceAccountFile: "key.json"
+email:
+  host: "smtp.example.com"
+  from: "not an address"
+  to: ["you@example.com", "you(at)example.com"]
+app:
+  timeLocation: "Mars/Olympus"
+`)
+
+	_, err := LoadConfig(path)
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	for _, expected := range []string{
+		"4 problem(s)",
+		"googleSheets.spreadsheetId is required",
+		"invalid mail address 'not an address' in email.from",
+		"invalid mail address 'you(at)example.com' in email.to",
+		"invalid app.timeLocation",
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected '%s' in error:\n%v", expected, err)
+		}
+	}
+}
+
+func TestLoadConfig_UnknownKeysWithValidationErrors(t *testing.T) {
+	path := writeConfig(t, `googleSheets:
+  sheetName: "Reminders"
+  serviceAccountFile: "key.json"
+email:
+  serviceUrl: "http://localhost:80"
+  host: "smtp.example.com"
+  from: "not an address"
+  to: ["you@example.com"]
+`)
+
+	_, err := LoadConfig(path)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	for _, expected := range []string{
+		"3 problem(s)",
+		"line 5: field serviceUrl not found",
+		"googleSheets.spreadsheetId is required",
+		"invalid mail address 'not an address' in email.from",
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected '%s' in error:\n%v", expected, err)
+		}
+	}
+}
+
+func TestLoadConfig_EscalationWithoutRecipients(t *testing.T) {
+	path := writeConfig(t, `googleSheets:
+  spreadsheetId: "id"
+  sheetName: "Reminders"
+  serviceAccountFile: "key.json"
+email:
+  host: "smtp.example.com"
+  from: "reminder@example.com"
+  to: ["you@example.com"]
+app:
+  escalation:
+    after: "4h"
+`)
+
+	_, err := LoadConfig(path)
+	if err == nil || !strings.Contains(err.Error(), "email.escalationTo is required for app.escalation.after") {
+		t.Errorf("expected error for escalation without recipients but got %v", err)
+	}
+}
+
+func TestLoadConfig_BackupKeepDefault(t *testing.T) {
+	path := writeConfig(t, `googleSheets:
+  spreadsheetId: "id"
+  sheetName: "Reminders"
+  serviceAccountFile: "key.json"
+email:
+  host: "smtp.example.com"
+  from: "reminder@example.com"
+  to: ["you@example.com"]
+backup:
+  directory: "backups"
+`)
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("found error %+v", err)
+	}
+	if cfg.Backup.Keep != 10 {
+		t.Errorf("expected 10 snapshots to be kept by default but got %d", cfg.Backup.Keep)
+	}
+}
+
+func TestConfig_GetSources(t *testing.T) {
+	global := Config{
+		GoogleSheets: GoogleSheetsConfig{
+			SpreadsheetID:  "global-id",
+			SheetName:      "Reminders",
+			ErrorSheetName: "Errors",
+			Forms:          FormsConfig{ResponsesSheetName: "Responses"},
+		},
+		Email: EmailConfig{
+			To:        []string{"global@example.com"},
+			Cc:        []string{"cc@example.com"},
+			LinkStyle: "wa.me",
+		},
+		App:    AppConfig{TimeLocation: "UTC"},
+		Backup: BackupConfig{Directory: "backups"},
+	}
+
+	tests := []struct {
+		name    string
+		sources []SourceConfig
+		want    func(global Config) []Config
+	}{
+		{
+			name: "without sources",
+			want: func(global Config) []Config { return []Config{global} },
+		}, {
+			name:    "falls back to global settings",
+			sources: []SourceConfig{{Name: "family", SheetName: "Family"}},
+			want: func(global Config) []Config {
+				result := global
+				result.Name = "family"
+				result.GoogleSheets.SheetName = "Family"
+				result.Backup.Directory = filepath.Join("backups", "family")
+				return []Config{result}
+			},
+		}, {
+			name: "overrides global settings",
+			sources: []SourceConfig{{
+				Name:               "club",
+				SpreadsheetID:      "club-id",
+				SheetName:          "Club",
+				ErrorSheetName:     "Club Errors",
+				ResponsesSheetName: "Club Responses",
+				TimeLocation:       "Europe/Berlin",
+				Email: SourceEmailConfig{
+					To:        []string{"board@example.com"},
+					Bcc:       []string{"archive@example.com"},
+					LinkStyle: "business",
+				},
+			}},
+			want: func(global Config) []Config {
+				result := global
+				result.Name = "club"
+				result.GoogleSheets.SpreadsheetID = "club-id"
+				result.GoogleSheets.SheetName = "Club"
+				result.GoogleSheets.ErrorSheetName = "Club Errors"
+				result.GoogleSheets.Forms.ResponsesSheetName = "Club Responses"
+				result.App.TimeLocation = "Europe/Berlin"
+				result.Email.To = []string{"board@example.com"}
+				result.Email.Bcc = []string{"archive@example.com"}
+				result.Email.LinkStyle = "business"
+				result.Backup.Directory = filepath.Join("backups", "club")
+				return []Config{result}
+			},
+		}, {
+			name:    "keeps sources apart",
+			sources: []SourceConfig{{Name: "family", SheetName: "Family"}, {Name: "work", SheetName: "Work", TimeLocation: "America/New_York"}},
+			want: func(global Config) []Config {
+				family := global
+				family.Name = "family"
+				family.GoogleSheets.SheetName = "Family"
+				family.Backup.Directory = filepath.Join("backups", "family")
+				work := global
+				work.Name = "work"
+				work.GoogleSheets.SheetName = "Work"
+				work.App.TimeLocation = "America/New_York"
+				work.Backup.Directory = filepath.Join("backups", "work")
+				return []Config{family, work}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := global
+			config.Sources = tt.sources
+			want := tt.want(global)
+			if len(tt.sources) == 0 {
+				want = tt.want(config)
+			}
+			if got := config.GetSources(); !reflect.DeepEqual(got, want) {
+				t.Errorf("GetSources() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_Sources(t *testing.T) {
+	base := `googleSheets:
+  spreadsheetId: "id"
+  sheetName: "Reminders"
+  serviceAccountFile: "key.json"
+email:
+  host: "smtp.example.com"
+  from: "reminder@example.com"
+  to: ["you@example.com"]
+`
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name: "valid",
+			content: `sources:
+  - name: family
+    sheetName: "Family"
+  - name: club
+    spreadsheetId: "other-id"
+    sheetName: "Family"
+backup:
+  directory: "backups"
+archive:
+  sheetName: "Archive"
+`,
+		}, {
+			name: "missing name",
+			content: `sources:
+  - sheetName: "Family"
+`,
+			wantErr: "sources[0].name is required",
+		}, {
+			name: "duplicate name",
+			content: `sources:
+  - name: family
+    sheetName: "Family"
+  - name: family
+    sheetName: "Club"
+`,
+			wantErr: "sources[1].name 'family' is not unique",
+		}, {
+			name: "duplicate sheet",
+			content: `sources:
+  - name: family
+    sheetName: "Family"
+  - name: club
+    sheetName: "Family"
+`,
+			wantErr: "sources 'family' and 'club' use the same sheet",
+		}, {
+			name: "shared backup tab",
+			content: `sources:
+  - name: family
+    sheetName: "Family"
+  - name: club
+    sheetName: "Club"
+backup:
+  sheetName: "Backups"
+`,
+			wantErr: "sources 'family' and 'club' share a spreadsheet, backup.sheetName cannot be used",
+		}, {
+			name: "shared archive tab",
+			content: `sources:
+  - name: family
+    sheetName: "Family"
+  - name: club
+    sheetName: "Club"
+archive:
+  sheetName: "Archive"
+`,
+			wantErr: "sources 'family' and 'club' share a spreadsheet, archive.sheetName cannot be used",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadConfig(writeConfig(t, base+tt.content))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("found error %+v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected '%s' in error but got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_SourceErrors(t *testing.T) {
+	path := writeConfig(t, `googleSheets:
+  sheetName: "Reminders"
+  serviceAccountFile: "key.json"
+email:
+  host: "smtp.example.com"
+  from: "reminder@example.com"
+  to: ["you@example.com"]
+sources:
+  - name: family
+    sheetName: "Family"
+    timeLocation: "Mars/Olympus"
+  - name: club
+    sheetName: "Club"
+    timeLocation: "Mars/Olympus"
+`)
+
+	_, err := LoadConfig(path)
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	for _, expected := range []string{
+		"3 problem(s)",
+		"  - googleSheets.spreadsheetId is required",
+		"source 'family': invalid app.timeLocation",
+		"source 'club': invalid app.timeLocation",
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected '%s' in error:\n%v", expected, err)
+		}
+	}
+}
+
+func TestLoadConfig_UnknownEnv(t *testing.T) {
+	t.Setenv("WR_EMAIL_PASSWORD", "secret")
+	path := writeConfig(t, `googleSheets:
+  sheetName: "Reminders"
+  serviceAccountFile: "key.json"
+email:
+  host: "smtp.example.com"
+  from: "reminder@example.com"
+  to: ["you@example.com"]
+`)
+
+	_, err := LoadConfig(path)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	for _, expected := range []string{
+		"2 problem(s)",
+		"unknown environment variable WR_EMAIL_PASSWORD",
+		"googleSheets.spreadsheetId is required",
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected '%s' in error:\n%v", expected, err)
+		}
+	}
+}
+
+func writeConfig(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
@@ -0,0 +1,177 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"gopkg.in/yaml.v2"
+)
+
+// every field can be overridden by an environment variable named after its
+// YAML path with the prefix WR_, e.g. WR_EMAIL_AUTH_PASSWORD for
+// email.auth.password. the variable with the suffix _FILE names a file with
+// the value instead, e.g. a mounted secret. lists of strings are comma
+// separated, other lists and maps are given as YAML, e.g. WR_SOURCES.
+
+const (
+	envPrefix     = "WR_"
+	envFileSuffix = "_FILE"
+)
+
+// applyEnv overrides the fields of the config with the values of the environment
+func applyEnv(config *Config, lookup func(name string) (string, bool)) error {
+	return applyEnvFields(reflect.ValueOf(config).Elem(), strings.TrimSuffix(envPrefix, "_"), lookup)
+}
+
+// unknownEnv reports variables with the prefix which do not name a field,
+// e.g. misspelled or outdated names, like unknown keys of the config file
+func unknownEnv(environ []string) []error {
+	known := make(map[string]bool)
+	_ = applyEnv(&Config{}, func(name string) (string, bool) {
+		known[name] = true
+		return "", false
+	})
+
+	names := make([]string, 0)
+	for _, variable := range environ {
+		name, _, _ := strings.Cut(variable, "=")
+		if strings.HasPrefix(name, envPrefix) && !known[name] {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	errs := make([]error, 0, len(names))
+	for _, name := range names {
+		errs = append(errs, fmt.Errorf("unknown environment variable %s", name))
+	}
+	return errs
+}
+
+// applyEnvFields overrides the fields of the struct, prefix is the name of the struct
+func applyEnvFields(value reflect.Value, prefix string, lookup func(name string) (string, bool)) error {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		tag := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if tag == "" || tag == "-" {
+			continue
+		}
+		name := prefix + "_" + envName(tag)
+
+		if err := applyEnvField(value.Field(i), name, lookup); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func applyEnvField(field reflect.Value, name string, lookup func(name string) (string, bool)) error {
+	switch {
+	case field.Kind() == reflect.Struct:
+		return applyEnvFields(field, name, lookup)
+	case field.Kind() == reflect.Pointer && field.Type().Elem().Kind() == reflect.Struct:
+		// the struct is only created if any of its fields is set
+		target := reflect.New(field.Type().Elem())
+		if !field.IsNil() {
+			target.Elem().Set(field.Elem())
+		}
+		if err := applyEnvFields(target.Elem(), name, lookup); err != nil {
+			return err
+		}
+		if !field.IsNil() || !reflect.DeepEqual(target.Elem().Interface(), reflect.Zero(field.Type().Elem()).Interface()) {
+			field.Set(target)
+		}
+		return nil
+	}
+
+	value, ok, err := lookupEnv(name, lookup)
+	if err != nil || !ok {
+		return err
+	}
+	if err := setEnvValue(field, value); err != nil {
+		return fmt.Errorf("invalid value of %s: %w", name, err)
+	}
+	return nil
+}
+
+// lookupEnv returns the value of the variable or the content of the file
+// named by the variable with the _FILE suffix
+func lookupEnv(name string, lookup func(name string) (string, bool)) (string, bool, error) {
+	value, ok := lookup(name)
+	file, fileOK := lookup(name + envFileSuffix)
+	if ok && fileOK {
+		return "", false, fmt.Errorf("only one of %s and %s%s can be set", name, name, envFileSuffix)
+	}
+	if !fileOK {
+		return value, ok, nil
+	}
+
+	content, err := os.ReadFile(filepath.Clean(file))
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read %s%s: %w", name, envFileSuffix, err)
+	}
+	return strings.TrimRight(string(content), "\r\n"), true, nil
+}
+
+func setEnvValue(field reflect.Value, value string) error {
+	switch {
+	case field.Type() == reflect.TypeOf(time.Duration(0)):
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(parsed))
+	case field.Kind() == reflect.String:
+		field.SetString(value)
+	case field.Kind() == reflect.Bool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(parsed)
+	case field.Kind() == reflect.Int:
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(parsed))
+	case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.String && !strings.HasPrefix(strings.TrimSpace(value), "["):
+		items := make([]string, 0)
+		for _, item := range strings.Split(value, ",") {
+			if trimmed := strings.TrimSpace(item); trimmed != "" {
+				items = append(items, trimmed)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	default:
+		target := reflect.New(field.Type())
+		if err := yaml.UnmarshalStrict([]byte(value), target.Interface()); err != nil {
+			return err
+		}
+		field.Set(target.Elem())
+	}
+	return nil
+}
+
+// envName converts a YAML key like "spreadsheetId" or "startTLS" to SPREADSHEET_ID or START_TLS
+func envName(key string) string {
+	runes := []rune(key)
+	var result strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			previous := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if !unicode.IsUpper(previous) || nextIsLower {
+				result.WriteRune('_')
+			}
+		}
+		result.WriteRune(unicode.ToUpper(r))
+	}
+	return result.String()
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEnvName(t *testing.T) {
+	for key, expected := range map[string]string{
+		"password":                "PASSWORD",
+		"spreadsheetId":           "SPREADSHEET_ID",
+		"startTLS":                "START_TLS",
+		"baseUrl":                 "BASE_URL",
+		"serviceAccountBase64Env": "SERVICE_ACCOUNT_BASE64_ENV",
+	} {
+		if actual := envName(key); actual != expected {
+			t.Errorf("envName(%s) = %s, want %s", key, actual, expected)
+		}
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	secretFile := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(secretFile, []byte("from file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	env := map[string]string{
+		"WR_EMAIL_AUTH_PASSWORD_FILE":         secretFile,
+		"WR_EMAIL_PORT":                       "25",
+		"WR_EMAIL_START_TLS":                  "true",
+		"WR_EMAIL_TIMEOUT":                    "10s",
+		"WR_EMAIL_TO":                         "a@example.com, b@example.com",
+		"WR_EMAIL_QUIET_HOURS_START":          "22:00",
+		"WR_GOOGLE_SHEETS_SPREADSHEET_ID":     "from env",
+		"WR_GOOGLE_SHEETS_COLUMN_ALIASES":     `{"Send Date": [Datum]}`,
+		"WR_SOURCES":                          "[{name: family, sheetName: Family}]",
+		"WR_GOOGLE_SHEETS_FORMS_LOCALE":       "",
+		"WR_APP_DATE_TIME_FORMAT_INPUT_DATES": "[\"1/2/2006\"]",
+	}
+	lookup := func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+
+	config := Config{GoogleSheets: GoogleSheetsConfig{SpreadsheetID: "from file", SheetName: "kept", Forms: FormsConfig{Locale: "en-US"}}}
+	if err := applyEnv(&config, lookup); err != nil {
+		t.Fatalf("found error %+v", err)
+	}
+
+	expected := Config{
+		GoogleSheets: GoogleSheetsConfig{
+			SpreadsheetID: "from env",
+			SheetName:     "kept",
+			ColumnAliases: map[string][]string{"Send Date": {"Datum"}},
+		},
+		Email: EmailConfig{
+			Port:       25,
+			StartTLS:   true,
+			Timeout:    10 * time.Second,
+			To:         []string{"a@example.com", "b@example.com"},
+			Auth:       SMTPAuthConfig{Password: "from file"},
+			QuietHours: &QuietHoursConfig{Start: "22:00"},
+		},
+		App:     AppConfig{DateTimeFormat: DateTimeFormatConfig{InputDates: []string{"1/2/2006"}}},
+		Sources: []SourceConfig{{Name: "family", SheetName: "Family"}},
+	}
+	if !reflect.DeepEqual(config, expected) {
+		t.Errorf("expected %+v\nbut got %+v", expected, config)
+	}
+}
+
+func TestApplyEnv_Errors(t *testing.T) {
+	tests := map[string]map[string]string{
+		"invalid int":         {"WR_EMAIL_PORT": "smtp"},
+		"value and file":      {"WR_EMAIL_AUTH_PASSWORD": "a", "WR_EMAIL_AUTH_PASSWORD_FILE": "b"},
+		"missing file":        {"WR_EMAIL_AUTH_PASSWORD_FILE": filepath.Join(t.TempDir(), "missing")},
+		"invalid yaml":        {"WR_SOURCES": "[{name: a, unknown: b}]"},
+		"invalid bool":        {"WR_EMAIL_QR_CODES": "maybe"},
+		"invalid nested bool": {"WR_EMAIL_QUIET_HOURS_WEEKDAYS_ONLY": "maybe"},
+	}
+	for name, env := range tests {
+		t.Run(name, func(t *testing.T) {
+			lookup := func(name string) (string, bool) {
+				value, ok := env[name]
+				return value, ok
+			}
+			if err := applyEnv(&Config{}, lookup); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestUnknownEnv(t *testing.T) {
+	environ := []string{
+		"HOME=/root",
+		"WR_EMAIL_AUTH_PASSWORD_FILE=/run/secrets/smtp-password",
+		"WR_EMAIL_QUIET_HOURS_START=22:00",
+		"WR_GOOGLE_SHEETS_SPREADSHEETID=id",
+		"WR_EMAIL_PASSWORD=secret",
+	}
+
+	errs := unknownEnv(environ)
+	actual := make([]string, 0, len(errs))
+	for _, err := range errs {
+		actual = append(actual, err.Error())
+	}
+	expected := []string{"unknown environment variable WR_EMAIL_PASSWORD", "unknown environment variable WR_GOOGLE_SHEETS_SPREADSHEETID"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v but got %v", expected, actual)
+	}
+}
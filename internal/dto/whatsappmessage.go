@@ -1,7 +1,18 @@
 package dto
 
+import "time"
+
 type WhatsappReminderConfig struct {
 	PhoneNumber string
 	MessageText string
 	MailAddress string
+	DueTime     time.Time
+	// ContactName is set if the phone number was resolved from a contact
+	ContactName string
+	// LeadTime is set for advance notices sent ahead of the due time
+	LeadTime time.Duration
+	// EntryID identifies the entry of the reminder for actions like snoozing
+	EntryID string
+	// Resend counts how often an unacknowledged reminder was sent again
+	Resend int
 }
@@ -0,0 +1,102 @@
+package duration
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parses durations like time.ParseDuration but additionally supports
+// days (d) and weeks (w), e.g. "1w", "7d", "1d12h" or "90m"
+
+const (
+	Day  = 24 * time.Hour
+	Week = 7 * Day
+)
+
+var longUnitRegex = regexp.MustCompile(`^(\d+)([dw])`)
+
+// Parse parses a single duration
+func Parse(value string) (time.Duration, error) {
+	remaining := strings.TrimSpace(value)
+	if remaining == "" {
+		return 0, fmt.Errorf("empty duration")
+	}
+
+	var result time.Duration
+	for {
+		match := longUnitRegex.FindStringSubmatch(remaining)
+		if match == nil {
+			break
+		}
+		amount, err := strconv.Atoi(match[1])
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration '%s': %w", value, err)
+		}
+		unit := Day
+		if match[2] == "w" {
+			unit = Week
+		}
+		result += time.Duration(amount) * unit
+		remaining = remaining[len(match[0]):]
+	}
+
+	if remaining != "" {
+		parsed, err := time.ParseDuration(remaining)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration '%s': %w", value, err)
+		}
+		result += parsed
+	}
+	if result < 0 {
+		return 0, fmt.Errorf("invalid duration '%s': must not be negative", value)
+	}
+	return result, nil
+}
+
+// ParseList parses comma separated durations and ignores empty items
+func ParseList(value string) ([]time.Duration, error) {
+	result := make([]time.Duration, 0)
+	for _, item := range strings.Split(value, ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		parsed, err := Parse(item)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, parsed)
+	}
+	return result, nil
+}
+
+// Format returns a short representation which can be parsed again, e.g. "1d12h"
+func Format(d time.Duration) string {
+	if d == 0 {
+		return "0s"
+	}
+
+	var stringBuilder strings.Builder
+	if d < 0 {
+		stringBuilder.WriteString("-")
+		d = -d
+	}
+	if days := d / Day; days > 0 {
+		fmt.Fprintf(&stringBuilder, "%dd", days)
+		d -= days * Day
+	}
+	if hours := d / time.Hour; hours > 0 {
+		fmt.Fprintf(&stringBuilder, "%dh", hours)
+		d -= hours * time.Hour
+	}
+	if minutes := d / time.Minute; minutes > 0 {
+		fmt.Fprintf(&stringBuilder, "%dm", minutes)
+		d -= minutes * time.Minute
+	}
+	if d > 0 {
+		stringBuilder.WriteString(d.String())
+	}
+	return stringBuilder.String()
+}
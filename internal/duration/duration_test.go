@@ -0,0 +1,76 @@
+package duration
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "90m", want: 90 * time.Minute},
+		{value: "7d", want: 7 * Day},
+		{value: " 1w ", want: Week},
+		{value: "1d12h", want: Day + 12*time.Hour},
+		{value: "1w2d3h4m", want: Week + 2*Day + 3*time.Hour + 4*time.Minute},
+		{value: "", wantErr: true},
+		{value: "d", wantErr: true},
+		{value: "1y", wantErr: true},
+		{value: "-1h", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := Parse(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Parse() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseList(t *testing.T) {
+	got, err := ParseList("7d, 1d,,2h")
+	if err != nil {
+		t.Errorf("found error %+v", err)
+	}
+	want := []time.Duration{7 * Day, Day, 2 * time.Hour}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseList() = %v, want %v", got, want)
+	}
+
+	if _, err := ParseList("7d,x"); err == nil {
+		t.Errorf("expected error for invalid item")
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		value time.Duration
+		want  string
+	}{
+		{value: 0, want: "0s"},
+		{value: 7 * Day, want: "7d"},
+		{value: Day + 12*time.Hour + 30*time.Minute, want: "1d12h30m"},
+		{value: 90 * time.Second, want: "1m30s"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			got := Format(tt.value)
+			if got != tt.want {
+				t.Errorf("Format() = %v, want %v", got, tt.want)
+			}
+			parsed, err := Parse(got)
+			if err != nil || parsed != tt.value {
+				t.Errorf("Parse(Format()) = %v (%v), want %v", parsed, err, tt.value)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package googleauth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// credentials are passed to the Google Sheets client as JSON document. a
+// service account key, the application default credentials file and
+// authorized user credentials of an OAuth client are all documents of this
+// kind. the Sheets client has no option for a token source, so credentials
+// of the metadata server (e.g. GKE workload identity) are no document and
+// cannot be used. a credential configuration file of workload identity
+// federation is a document and works on Kubernetes without a key.
+
+type Type string
+
+const (
+	// ServiceAccount uses a service account key from a file or an environment variable
+	ServiceAccount Type = "serviceAccount"
+	// ApplicationDefault uses the credentials file found like Google's client libraries do
+	ApplicationDefault Type = "adc"
+	// OAuthUser authorizes a user once in the browser and caches the refresh token
+	OAuthUser Type = "oauth"
+)
+
+// ParseType returns ServiceAccount for an empty value
+func ParseType(value string) (Type, error) {
+	switch Type(value) {
+	case "", ServiceAccount:
+		return ServiceAccount, nil
+	case ApplicationDefault, OAuthUser:
+		return Type(value), nil
+	}
+	return "", fmt.Errorf("unknown auth type '%s', use %s, %s or %s", value, ServiceAccount, ApplicationDefault, OAuthUser)
+}
+
+// ServiceAccountKey reads the key from the base64 encoded environment variable
+// if it is set and from the file otherwise
+func ServiceAccountKey(file string, base64Env string) ([]byte, error) {
+	if base64Env != "" {
+		if value := strings.TrimSpace(os.Getenv(base64Env)); value != "" {
+			key, err := base64.StdEncoding.DecodeString(value)
+			if err != nil {
+				return nil, fmt.Errorf("environment variable %s is not base64 encoded: %w", base64Env, err)
+			}
+			if !json.Valid(key) {
+				return nil, fmt.Errorf("environment variable %s does not contain a JSON key", base64Env)
+			}
+			return key, nil
+		}
+		if file == "" {
+			return nil, fmt.Errorf("environment variable %s is not set", base64Env)
+		}
+	}
+
+	key, err := os.ReadFile(filepath.Clean(file))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account file %s: %w", file, err)
+	}
+	return key, nil
+}
+
+// ApplicationDefaultCredentials reads the file named by GOOGLE_APPLICATION_CREDENTIALS
+// or the file written by "gcloud auth application-default login". Unlike
+// Google's client libraries, it does not fall back to the metadata server.
+func ApplicationDefaultCredentials() ([]byte, error) {
+	if file := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"); file != "" {
+		credentials, err := os.ReadFile(filepath.Clean(file))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read GOOGLE_APPLICATION_CREDENTIALS: %w", err)
+		}
+		return credentials, nil
+	}
+
+	file := wellKnownFile()
+	credentials, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("no application default credentials file found, set GOOGLE_APPLICATION_CREDENTIALS or run 'gcloud auth application-default login' (credentials of the metadata server are not supported)")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", file, err)
+	}
+	return credentials, nil
+}
+
+func wellKnownFile() string {
+	const name = "application_default_credentials.json"
+	if runtime.GOOS == "windows" {
+		return filepath.Join(os.Getenv("APPDATA"), "gcloud", name)
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", "gcloud", name)
+}
@@ -0,0 +1,167 @@
+package googleauth
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServiceAccountKey(t *testing.T) {
+	key := `{"type":"service_account"}`
+	file := filepath.Join(t.TempDir(), "key.json")
+	if err := os.WriteFile(file, []byte(`{"type":"from_file"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_KEY", base64.StdEncoding.EncodeToString([]byte(key)))
+	t.Setenv("TEST_INVALID_KEY", "not base64")
+
+	tests := []struct {
+		name      string
+		file      string
+		base64Env string
+		want      string
+		wantErr   bool
+	}{
+		{name: "file", file: file, want: `{"type":"from_file"}`},
+		{name: "environment variable", file: file, base64Env: "TEST_KEY", want: key},
+		{name: "unset environment variable falls back to file", file: file, base64Env: "TEST_UNSET", want: `{"type":"from_file"}`},
+		{name: "unset environment variable without file", base64Env: "TEST_UNSET", wantErr: true},
+		{name: "invalid environment variable", base64Env: "TEST_INVALID_KEY", wantErr: true},
+		{name: "missing file", file: filepath.Join(t.TempDir(), "missing.json"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ServiceAccountKey(tt.file, tt.base64Env)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ServiceAccountKey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if string(got) != tt.want {
+				t.Errorf("ServiceAccountKey() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplicationDefaultCredentials(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "adc.json")
+	if err := os.WriteFile(file, []byte(`{"type":"external_account"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", file)
+
+	got, err := ApplicationDefaultCredentials()
+	if err != nil {
+		t.Fatalf("found error %+v", err)
+	}
+	if string(got) != `{"type":"external_account"}` {
+		t.Errorf("unexpected credentials %s", got)
+	}
+}
+
+func TestOAuthUserCredentials(t *testing.T) {
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if err := request.ParseForm(); err != nil || request.PostForm.Get("client_secret") != "secret" {
+			writer.WriteHeader(http.StatusUnauthorized)
+			_, _ = fmt.Fprint(writer, `{"error":"invalid_client"}`)
+			return
+		}
+		switch {
+		case request.PostForm.Get("grant_type") == "authorization_code" && request.PostForm.Get("code") == "test-code":
+			_, _ = fmt.Fprint(writer, `{"access_token":"access","refresh_token":"refresh"}`)
+		case request.PostForm.Get("grant_type") == "refresh_token" && request.PostForm.Get("refresh_token") == "refresh":
+			_, _ = fmt.Fprint(writer, `{"access_token":"access"}`)
+		default:
+			writer.WriteHeader(http.StatusBadRequest)
+			_, _ = fmt.Fprint(writer, `{"error":"invalid_grant"}`)
+		}
+	}))
+	defer tokenServer.Close()
+
+	directory := t.TempDir()
+	clientSecretFile := filepath.Join(directory, "client.json")
+	content := fmt.Sprintf(`{"installed":{"client_id":"id","client_secret":"secret","auth_uri":"https://accounts.example.com/auth","token_uri":"%s"}}`, tokenServer.URL)
+	if err := os.WriteFile(clientSecretFile, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tokenCacheFile := filepath.Join(directory, "cache", "token.json")
+
+	// the browser follows the redirect of the authorization server with a code
+	browser := func(authURL string) {
+		parsed, err := url.Parse(authURL)
+		if err != nil {
+			t.Errorf("invalid auth URL %s", authURL)
+			return
+		}
+		query := parsed.Query()
+		go func() {
+			response, err := http.Get(query.Get("redirect_uri") + "?code=test-code&state=" + query.Get("state"))
+			if err == nil {
+				_ = response.Body.Close()
+			}
+		}()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	credentials, err := OAuthUserCredentials(ctx, clientSecretFile, tokenCacheFile, browser)
+	if err != nil {
+		t.Fatalf("found error %+v", err)
+	}
+	var user authorizedUser
+	if err := json.Unmarshal(credentials, &user); err != nil {
+		t.Fatalf("found error %+v", err)
+	}
+	expected := authorizedUser{Type: "authorized_user", ClientID: "id", ClientSecret: "secret", RefreshToken: "refresh"}
+	if user != expected {
+		t.Errorf("expected %+v but got %+v", expected, user)
+	}
+
+	// the cached credentials are used without authorization
+	cached, err := OAuthUserCredentials(ctx, clientSecretFile, tokenCacheFile, func(string) {
+		t.Error("expected no authorization with cached credentials")
+	})
+	if err != nil || string(cached) != string(credentials) {
+		t.Errorf("expected cached credentials but got %s (%v)", cached, err)
+	}
+	if cached, err := OAuthUserCredentials(ctx, clientSecretFile, tokenCacheFile, nil); err != nil || string(cached) != string(credentials) {
+		t.Errorf("expected cached credentials without browser but got %s (%v)", cached, err)
+	}
+
+	// a revoked refresh token is only replaced if the user can be asked
+	revoked := `{"type":"authorized_user","client_id":"id","client_secret":"secret","refresh_token":"revoked"}`
+	if err := os.WriteFile(tokenCacheFile, []byte(revoked), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OAuthUserCredentials(ctx, clientSecretFile, tokenCacheFile, nil); err == nil || !strings.Contains(err.Error(), "revoked") {
+		t.Errorf("expected error for revoked token without browser but got %v", err)
+	}
+	renewed, err := OAuthUserCredentials(ctx, clientSecretFile, tokenCacheFile, browser)
+	if err != nil || string(renewed) != string(credentials) {
+		t.Errorf("expected renewed credentials but got %s (%v)", renewed, err)
+	}
+
+	// without browser, the user cannot be asked for a missing token
+	if _, err := OAuthUserCredentials(ctx, clientSecretFile, filepath.Join(directory, "missing.json"), nil); err == nil {
+		t.Error("expected error without cached token")
+	}
+}
+
+func TestParseType(t *testing.T) {
+	for value, expected := range map[string]Type{"": ServiceAccount, "serviceAccount": ServiceAccount, "adc": ApplicationDefault, "oauth": OAuthUser} {
+		if actual, err := ParseType(value); err != nil || actual != expected {
+			t.Errorf("ParseType(%s) = %s, %v, want %s", value, actual, err, expected)
+		}
+	}
+	if _, err := ParseType("apiKey"); err == nil {
+		t.Error("expected error for unknown type")
+	}
+}
@@ -0,0 +1,262 @@
+package googleauth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// the OAuth flow for installed applications: the user opens the
+// authorization URL, Google redirects to a listener on the loopback interface
+// with a code which is exchanged for a refresh token. the refresh token is
+// cached as authorized user credentials, so the browser is only needed once.
+
+const sheetsScope = "https://www.googleapis.com/auth/spreadsheets"
+
+type clientSecret struct {
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	AuthURI      string `json:"auth_uri"`
+	TokenURI     string `json:"token_uri"`
+}
+
+type authorizedUser struct {
+	Type         string `json:"type"`
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// errRevoked is returned for a refresh token which was revoked or expired
+var errRevoked = errors.New("the refresh token was revoked or expired")
+
+// OAuthUserCredentials returns the cached credentials or authorizes the user
+// and caches the credentials. The cached refresh token is checked first, the
+// user is asked again if it was revoked. open is called with the URL to open
+// in a browser, if it is nil the user cannot be asked and an error is
+// returned instead.
+func OAuthUserCredentials(ctx context.Context, clientSecretFile string, tokenCacheFile string, open func(authURL string)) ([]byte, error) {
+	client, err := readClientSecret(clientSecretFile)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials, err := os.ReadFile(filepath.Clean(tokenCacheFile))
+	switch {
+	case err == nil:
+		err = checkCached(ctx, client, credentials)
+		if err == nil {
+			return credentials, nil
+		}
+		if !errors.Is(err, errRevoked) {
+			return nil, fmt.Errorf("invalid token cache %s: %w", tokenCacheFile, err)
+		}
+		if open == nil {
+			return nil, fmt.Errorf("token cache %s: %w, authorize again with the cli and replace the token cache", tokenCacheFile, err)
+		}
+		log.Printf("token cache %s: %v, authorizing again", tokenCacheFile, err)
+	case !errors.Is(err, os.ErrNotExist):
+		return nil, fmt.Errorf("failed to read token cache %s: %w", tokenCacheFile, err)
+	case open == nil:
+		return nil, fmt.Errorf("no token cached in %s, authorize once with the cli and provide the token cache", tokenCacheFile)
+	}
+
+	refreshToken, err := authorize(ctx, client, open)
+	if err != nil {
+		return nil, fmt.Errorf("authorization failed: %w", err)
+	}
+
+	credentials, err = json.Marshal(authorizedUser{
+		Type:         "authorized_user",
+		ClientID:     client.ClientID,
+		ClientSecret: client.ClientSecret,
+		RefreshToken: refreshToken,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(tokenCacheFile), 0o700); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Clean(tokenCacheFile), credentials, 0o600); err != nil {
+		return nil, fmt.Errorf("failed to write token cache %s: %w", tokenCacheFile, err)
+	}
+	return credentials, nil
+}
+
+// checkCached requests an access token with the cached refresh token. If the
+// token server cannot be reached, the cached credentials are used anyway.
+func checkCached(ctx context.Context, client clientSecret, credentials []byte) error {
+	var user authorizedUser
+	if err := json.Unmarshal(credentials, &user); err != nil {
+		return err
+	}
+	if user.RefreshToken == "" {
+		return errors.New("no refresh token")
+	}
+	_, err := requestToken(ctx, client.TokenURI, url.Values{
+		"client_id":     {user.ClientID},
+		"client_secret": {user.ClientSecret},
+		"refresh_token": {user.RefreshToken},
+		"grant_type":    {"refresh_token"},
+	})
+	var statusErr *tokenStatusError
+	if err != nil && !errors.Is(err, errRevoked) && !errors.As(err, &statusErr) {
+		log.Printf("could not check the cached token, using it anyway: %v", err)
+		return nil
+	}
+	return err
+}
+
+// readClientSecret reads the client secret file downloaded from the Google
+// Cloud console for a desktop or web client
+func readClientSecret(file string) (clientSecret, error) {
+	content, err := os.ReadFile(filepath.Clean(file))
+	if err != nil {
+		return clientSecret{}, fmt.Errorf("failed to read client secret file %s: %w", file, err)
+	}
+	var document struct {
+		Installed *clientSecret `json:"installed"`
+		Web       *clientSecret `json:"web"`
+	}
+	if err := json.Unmarshal(content, &document); err != nil {
+		return clientSecret{}, fmt.Errorf("invalid client secret file %s: %w", file, err)
+	}
+
+	client := document.Installed
+	if client == nil {
+		client = document.Web
+	}
+	if client == nil || client.ClientID == "" || client.AuthURI == "" || client.TokenURI == "" {
+		return clientSecret{}, fmt.Errorf("client secret file %s has no OAuth client", file)
+	}
+	return *client, nil
+}
+
+// authorize returns the refresh token of the user
+func authorize(ctx context.Context, client clientSecret, open func(authURL string)) (string, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	redirectURI := "http://" + listener.Addr().String() + "/"
+	state, err := randomState()
+	if err != nil {
+		return "", err
+	}
+
+	codes := make(chan string, 1)
+	server := &http.Server{
+		ReadHeaderTimeout: 10 * time.Second,
+		Handler: http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			query := request.URL.Query()
+			if query.Get("state") != state || query.Get("code") == "" {
+				http.Error(writer, "invalid authorization response", http.StatusBadRequest)
+				return
+			}
+			_, _ = fmt.Fprintln(writer, "Authorization completed, you can close this window.")
+			select {
+			case codes <- query.Get("code"):
+			default:
+			}
+		}),
+	}
+	go func() { _ = server.Serve(listener) }()
+	defer func() { _ = server.Close() }()
+
+	open(client.AuthURI + "?" + url.Values{
+		"client_id":     {client.ClientID},
+		"redirect_uri":  {redirectURI},
+		"response_type": {"code"},
+		"scope":         {sheetsScope},
+		"access_type":   {"offline"},
+		"prompt":        {"consent"},
+		"state":         {state},
+	}.Encode())
+
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case code := <-codes:
+		return exchange(ctx, client, code, redirectURI)
+	}
+}
+
+func exchange(ctx context.Context, client clientSecret, code string, redirectURI string) (string, error) {
+	token, err := requestToken(ctx, client.TokenURI, url.Values{
+		"client_id":     {client.ClientID},
+		"client_secret": {client.ClientSecret},
+		"code":          {code},
+		"redirect_uri":  {redirectURI},
+		"grant_type":    {"authorization_code"},
+	})
+	if err != nil {
+		return "", err
+	}
+	if token.RefreshToken == "" {
+		return "", errors.New("token response has no refresh token")
+	}
+	return token.RefreshToken, nil
+}
+
+type tokenResponse struct {
+	RefreshToken     string `json:"refresh_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// tokenStatusError is returned if the token server rejected the request
+type tokenStatusError struct {
+	status   int
+	response tokenResponse
+}
+
+func (err *tokenStatusError) Error() string {
+	return fmt.Sprintf("token request failed with status %d: %s %s", err.status, err.response.Error, err.response.ErrorDescription)
+}
+
+// requestToken returns errRevoked if the token server rejects the grant
+func requestToken(ctx context.Context, tokenURI string, form url.Values) (tokenResponse, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return tokenResponse{}, err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return tokenResponse{}, err
+	}
+	defer func() { _ = response.Body.Close() }()
+
+	var token tokenResponse
+	if err := json.NewDecoder(response.Body).Decode(&token); err != nil {
+		return tokenResponse{}, fmt.Errorf("invalid token response: %w", err)
+	}
+	if response.StatusCode != http.StatusOK {
+		if token.Error == "invalid_grant" && form.Get("grant_type") == "refresh_token" {
+			return tokenResponse{}, errRevoked
+		}
+		return tokenResponse{}, &tokenStatusError{status: response.StatusCode, response: token}
+	}
+	return token, nil
+}
+
+func randomState() (string, error) {
+	state := make([]byte, 16)
+	if _, err := rand.Read(state); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(state), nil
+}
@@ -0,0 +1,106 @@
+package quiethours
+
+import (
+	"fmt"
+	"time"
+)
+
+// quiet hours define when no reminders are delivered, e.g. from 22:00 to
+// 07:00. reminders which become due during quiet hours are held until the
+// next delivery window starts. optionally weekends are quiet as a whole.
+
+const clockLayout = "15:04"
+
+type QuietHours struct {
+	start        time.Duration
+	end          time.Duration
+	enabled      bool
+	weekdaysOnly bool
+	location     *time.Location
+}
+
+// Parse creates quiet hours from clock times like "22:00" in the given location.
+// If start and end are empty, only the weekdays restriction applies.
+func Parse(start string, end string, weekdaysOnly bool, location *time.Location) (QuietHours, error) {
+	result := QuietHours{
+		weekdaysOnly: weekdaysOnly,
+		location:     location,
+	}
+	if start == "" && end == "" {
+		return result, nil
+	}
+
+	var err error
+	if result.start, err = parseClock(start); err != nil {
+		return QuietHours{}, fmt.Errorf("invalid quiet hours start: %w", err)
+	}
+	if result.end, err = parseClock(end); err != nil {
+		return QuietHours{}, fmt.Errorf("invalid quiet hours end: %w", err)
+	}
+	result.enabled = result.start != result.end
+	return result, nil
+}
+
+func parseClock(value string) (time.Duration, error) {
+	clock, err := time.Parse(clockLayout, value)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute, nil
+}
+
+// IsQuiet returns whether reminders must be held at the given time
+func (q QuietHours) IsQuiet(t time.Time) bool {
+	local := q.in(t)
+	if q.weekdaysOnly && (local.Weekday() == time.Saturday || local.Weekday() == time.Sunday) {
+		return true
+	}
+	if !q.enabled {
+		return false
+	}
+
+	// boundaries are wall clock times, so they stay in place on days with a
+	// daylight saving time change
+	start := at(local, q.start)
+	end := at(local, q.end)
+	if q.start < q.end {
+		return !local.Before(start) && local.Before(end)
+	}
+	// quiet hours span midnight
+	return !local.Before(start) || local.Before(end)
+}
+
+// NextDelivery returns the start of the next delivery window at or after the given time
+func (q QuietHours) NextDelivery(t time.Time) time.Time {
+	if !q.IsQuiet(t) {
+		return t
+	}
+
+	// a delivery window can only start at midnight or at the end of quiet hours
+	local := q.in(t)
+	for day := 0; day <= 8; day++ {
+		dayStart := midnight(local).AddDate(0, 0, day)
+		for _, candidate := range []time.Time{dayStart, at(dayStart, q.end)} {
+			if candidate.After(local) && !q.IsQuiet(candidate) {
+				return candidate
+			}
+		}
+	}
+	return t
+}
+
+func (q QuietHours) in(t time.Time) time.Time {
+	if q.location == nil {
+		return t
+	}
+	return t.In(q.location)
+}
+
+func midnight(t time.Time) time.Time {
+	return at(t, 0)
+}
+
+// at returns the given clock time on the day of t in its location
+func at(t time.Time, clock time.Duration) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), int(clock/time.Hour), int(clock%time.Hour/time.Minute), 0, 0, t.Location())
+}
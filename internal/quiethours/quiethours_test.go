@@ -0,0 +1,109 @@
+package quiethours
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQuietHours_IsQuiet(t *testing.T) {
+	location := getDefaultTestLocation(t)
+	overnight, err := Parse("22:00", "07:00", false, location)
+	if err != nil {
+		t.Errorf("found error %+v", err)
+	}
+	daytime, err := Parse("12:00", "14:00", false, location)
+	if err != nil {
+		t.Errorf("found error %+v", err)
+	}
+	weekdays, err := Parse("", "", true, location)
+	if err != nil {
+		t.Errorf("found error %+v", err)
+	}
+	none, err := Parse("", "", false, location)
+	if err != nil {
+		t.Errorf("found error %+v", err)
+	}
+
+	// 2024-03-08 is a Friday
+	tests := []struct {
+		name        string
+		quietHours  QuietHours
+		time        time.Time
+		wantIsQuiet bool
+	}{
+		{name: "overnight late", quietHours: overnight, time: time.Date(2024, 3, 8, 23, 0, 0, 0, location), wantIsQuiet: true},
+		{name: "overnight early", quietHours: overnight, time: time.Date(2024, 3, 8, 2, 0, 0, 0, location), wantIsQuiet: true},
+		{name: "overnight end", quietHours: overnight, time: time.Date(2024, 3, 8, 7, 0, 0, 0, location), wantIsQuiet: false},
+		{name: "overnight day", quietHours: overnight, time: time.Date(2024, 3, 8, 12, 0, 0, 0, location), wantIsQuiet: false},
+		{name: "overnight other location", quietHours: overnight, time: time.Date(2024, 3, 8, 1, 0, 0, 0, time.UTC), wantIsQuiet: true},
+		{name: "daytime inside", quietHours: daytime, time: time.Date(2024, 3, 8, 13, 0, 0, 0, location), wantIsQuiet: true},
+		{name: "daytime outside", quietHours: daytime, time: time.Date(2024, 3, 8, 15, 0, 0, 0, location), wantIsQuiet: false},
+		{name: "weekday", quietHours: weekdays, time: time.Date(2024, 3, 8, 15, 0, 0, 0, location), wantIsQuiet: false},
+		{name: "weekend", quietHours: weekdays, time: time.Date(2024, 3, 9, 15, 0, 0, 0, location), wantIsQuiet: true},
+		{name: "none", quietHours: none, time: time.Date(2024, 3, 9, 3, 0, 0, 0, location), wantIsQuiet: false},
+		// 2024-03-31 and 2024-10-27 change daylight saving time
+		{name: "overnight end on spring change", quietHours: overnight, time: time.Date(2024, 3, 31, 7, 0, 0, 0, location), wantIsQuiet: false},
+		{name: "overnight before end on spring change", quietHours: overnight, time: time.Date(2024, 3, 31, 6, 30, 0, 0, location), wantIsQuiet: true},
+		{name: "overnight end on autumn change", quietHours: overnight, time: time.Date(2024, 10, 27, 7, 0, 0, 0, location), wantIsQuiet: false},
+		{name: "overnight before end on autumn change", quietHours: overnight, time: time.Date(2024, 10, 27, 6, 30, 0, 0, location), wantIsQuiet: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.quietHours.IsQuiet(tt.time); got != tt.wantIsQuiet {
+				t.Errorf("IsQuiet() = %v, want %v", got, tt.wantIsQuiet)
+			}
+		})
+	}
+}
+
+func TestQuietHours_NextDelivery(t *testing.T) {
+	location := getDefaultTestLocation(t)
+	overnight, err := Parse("22:00", "07:00", false, location)
+	if err != nil {
+		t.Errorf("found error %+v", err)
+	}
+	overnightWeekdays, err := Parse("22:00", "07:00", true, location)
+	if err != nil {
+		t.Errorf("found error %+v", err)
+	}
+
+	tests := []struct {
+		name       string
+		quietHours QuietHours
+		time       time.Time
+		want       time.Time
+	}{
+		{name: "not quiet", quietHours: overnight, time: time.Date(2024, 3, 8, 12, 0, 0, 0, location), want: time.Date(2024, 3, 8, 12, 0, 0, 0, location)},
+		{name: "before midnight", quietHours: overnight, time: time.Date(2024, 3, 7, 23, 0, 0, 0, location), want: time.Date(2024, 3, 8, 7, 0, 0, 0, location)},
+		{name: "after midnight", quietHours: overnight, time: time.Date(2024, 3, 8, 2, 0, 0, 0, location), want: time.Date(2024, 3, 8, 7, 0, 0, 0, location)},
+		{name: "spring change", quietHours: overnight, time: time.Date(2024, 3, 31, 1, 0, 0, 0, location), want: time.Date(2024, 3, 31, 7, 0, 0, 0, location)},
+		{name: "autumn change", quietHours: overnight, time: time.Date(2024, 10, 27, 1, 0, 0, 0, location), want: time.Date(2024, 10, 27, 7, 0, 0, 0, location)},
+		{name: "friday night", quietHours: overnightWeekdays, time: time.Date(2024, 3, 8, 23, 0, 0, 0, location), want: time.Date(2024, 3, 11, 7, 0, 0, 0, location)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.quietHours.NextDelivery(tt.time); !got.Equal(tt.want) {
+				t.Errorf("NextDelivery() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	if _, err := Parse("22:00", "", false, time.UTC); err == nil {
+		t.Errorf("expected error for missing end")
+	}
+	if _, err := Parse("25:00", "07:00", false, time.UTC); err == nil {
+		t.Errorf("expected error for invalid start")
+	}
+}
+
+func getDefaultTestLocation(t *testing.T) *time.Location {
+	location, err := time.LoadLocation("Europe/Berlin")
+
+	if err != nil {
+		t.Errorf("found error %+v", err)
+	}
+
+	return location
+}
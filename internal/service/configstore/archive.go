@@ -0,0 +1,139 @@
+package configstore
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jo-hoe/whatsapp-reminder/internal/timeformat"
+)
+
+// statusColumn holds the final status of an archived entry
+const statusColumn = "Status"
+
+// StatusSent is the final status of archived entries which were sent
+const StatusSent = "sent"
+
+// sourceColumn names the source of an archived entry if there are multiple sources
+const sourceColumn = "Reminder Source"
+
+// Archive keeps entries which are removed from the sheet after the retention time
+type Archive interface {
+	Archive(entries []ConfigEntry) error
+}
+
+// CSVArchive appends archived entries as rows to a CSV tab or file. Existing
+// rows are kept and columns are matched by their header.
+type CSVArchive struct {
+	openReader openReader
+	openWriter openWriter
+	// source is written to each row if not empty, so entries of several
+	// sources can be told apart
+	source string
+	// store formats the rows the same way as the sheet
+	store *CSVConfigStore
+}
+
+func NewCSVArchive(openReader openReader, openWriter openWriter, source string, defaultLocation time.Location, format timeformat.Format) *CSVArchive {
+	return &CSVArchive{
+		openReader: openReader,
+		openWriter: openWriter,
+		source:     source,
+		store: &CSVConfigStore{
+			defaultLocation: defaultLocation,
+			format:          format,
+			columns:         DefaultColumns(),
+		},
+	}
+}
+
+// NewFileArchive archives to a local CSV file which is created on first use
+func NewFileArchive(path string, source string, defaultLocation time.Location, format timeformat.Format) *CSVArchive {
+	path = filepath.Clean(path)
+	openReader := func() (io.Reader, error) {
+		content, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			return bytes.NewReader(nil), nil
+		}
+		return bytes.NewReader(content), err
+	}
+	openWriter := func() (io.Writer, error) {
+		return fileWriter{path: path}, nil
+	}
+	return NewCSVArchive(openReader, openWriter, source, defaultLocation, format)
+}
+
+// fileWriter replaces the file with the content of each write. The content is
+// written to a temporary file first, so the file is never left half written.
+type fileWriter struct {
+	path string
+}
+
+func (writer fileWriter) Write(p []byte) (int, error) {
+	file, err := os.CreateTemp(filepath.Dir(writer.path), filepath.Base(writer.path)+".tmp-*")
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = os.Remove(file.Name()) }()
+
+	if _, err := file.Write(p); err != nil {
+		_ = file.Close()
+		return 0, err
+	}
+	if err := file.Close(); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(file.Name(), writer.path); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (archive *CSVArchive) Archive(entries []ConfigEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	headers := append(append([]string{}, header...), statusColumn)
+	if archive.source != "" {
+		headers = append(headers, sourceColumn)
+	}
+	headers = archive.store.appendHeaders(headers, entries)
+	rows := make([][]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Status == "" {
+			entry.Status = StatusSent
+		}
+		location, err := entry.Location(&archive.store.defaultLocation)
+		if err != nil {
+			location = &archive.store.defaultLocation
+		}
+		formatter := rowFormat{format: archive.store.format, location: location}
+
+		cells := make([]string, len(headers))
+		for j, header := range headers {
+			if header == sourceColumn && archive.source != "" {
+				cells[j] = archive.source
+				continue
+			}
+			cells[j] = archive.store.writeCell(entry, header, formatter)
+		}
+		rows = append(rows, cells)
+	}
+	return appendToTab(archive.openReader, archive.openWriter, headers, rows)
+}
+
+type ArchiveMock struct {
+	Archived      []ConfigEntry
+	ArchiveResult error
+}
+
+func (archive *ArchiveMock) Archive(entries []ConfigEntry) error {
+	if archive.ArchiveResult != nil {
+		return archive.ArchiveResult
+	}
+	archive.Archived = append(archive.Archived, entries...)
+	return nil
+}
@@ -0,0 +1,61 @@
+package configstore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jo-hoe/whatsapp-reminder/internal/dto"
+	"github.com/jo-hoe/whatsapp-reminder/internal/timeformat"
+)
+
+func TestCSVArchive_Archive(t *testing.T) {
+	location := getDefaultTestLocation(t)
+	entries := []ConfigEntry{
+		{
+			WhatsappReminderConfig: dto.WhatsappReminderConfig{MessageText: "Test 1", PhoneNumber: "+49123"},
+			CreationTime:           time.Date(2022, 7, 20, 13, 0, 0, 0, location),
+			DueTime:                time.Date(2022, 7, 21, 9, 0, 0, 0, location),
+			ProcessTime:            time.Date(2022, 7, 21, 9, 5, 0, 0, location),
+		}, {
+			WhatsappReminderConfig: dto.WhatsappReminderConfig{MessageText: "Test 2"},
+			DueTime:                time.Date(2022, 7, 22, 9, 0, 0, 0, location),
+			ProcessTime:            time.Date(2022, 7, 22, 9, 0, 0, 0, location),
+			Status:                 StatusSkipped,
+			Variables:              map[string]string{"Name": "Anna"},
+		},
+	}
+
+	tab := &memoryTab{content: "Message Text,Status\nOld,sent\n"}
+	if err := NewCSVArchive(tab.openReader, tab.openWriter, "", *location, timeformat.Default()).Archive(entries[:1]); err != nil {
+		t.Fatalf("found error %+v", err)
+	}
+	expected := "Message Text,Status,Timestamp,Send Date,Send Time,Phone Number,Mail Address,Process Time\n" +
+		"Old,sent\n" +
+		"Test 1,sent,20/07/2022 13:00:00,21/07/2022,09:00:00,+49123,,21/07/2022 09:05:00\n"
+	if tab.content != expected {
+		t.Errorf("expected tab:\n%s\nbut got:\n%s", expected, tab.content)
+	}
+
+	path := filepath.Join(t.TempDir(), "archive.csv")
+	archive := NewFileArchive(path, "family", *location, timeformat.Default())
+	for _, entry := range entries {
+		if err := archive.Archive([]ConfigEntry{entry}); err != nil {
+			t.Fatalf("found error %+v", err)
+		}
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("found error %+v", err)
+	}
+	expected = "Timestamp,Message Text,Send Date,Send Time,Phone Number,Mail Address,Process Time,Status,Reminder Source,Name\n" +
+		"20/07/2022 13:00:00,Test 1,21/07/2022,09:00:00,+49123,,21/07/2022 09:05:00,sent,family\n" +
+		",Test 2,22/07/2022,09:00:00,,,22/07/2022 09:00:00,skipped,family,Anna\n"
+	if string(content) != expected {
+		t.Errorf("expected file:\n%s\nbut got:\n%s", expected, content)
+	}
+	if files, err := os.ReadDir(filepath.Dir(path)); err != nil || len(files) != 1 {
+		t.Errorf("expected no temporary files to be left but got %v (%v)", files, err)
+	}
+}
@@ -0,0 +1,136 @@
+package configstore
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+	"time"
+)
+
+// SnapshotLayout names snapshots by the time they were taken in UTC
+const SnapshotLayout = "20060102T150405Z"
+
+// Snapshot is the content of the sheet at a point in time
+type Snapshot struct {
+	Time time.Time
+	Data [][]string
+}
+
+// BackupTarget keeps snapshots of the sheet
+type BackupTarget interface {
+	// Save adds the snapshot and deletes the oldest snapshots above the retention
+	Save(snapshot Snapshot) error
+	// Snapshots returns the times of all snapshots, oldest first
+	Snapshots() ([]time.Time, error)
+	Load(snapshotTime time.Time) (Snapshot, error)
+}
+
+// BackupConfigStore saves a snapshot of the sheet each time before it is
+// written, unless the sheet did not change since the last snapshot
+type BackupConfigStore struct {
+	store      ConfigStore
+	openReader openReader
+	openWriter openWriter
+	target     BackupTarget
+}
+
+func NewBackupConfigStore(store ConfigStore, openReader openReader, openWriter openWriter, target BackupTarget) *BackupConfigStore {
+	return &BackupConfigStore{
+		store:      store,
+		openReader: openReader,
+		openWriter: openWriter,
+		target:     target,
+	}
+}
+
+func (service *BackupConfigStore) GetConfigs() ([]ConfigEntry, error) {
+	return service.store.GetConfigs()
+}
+
+// OverwriteConfigs writes the entries even if the snapshot could not be
+// saved, so a failing backup does not stop reminders from being marked
+func (service *BackupConfigStore) OverwriteConfigs(configs []ConfigEntry) error {
+	if err := service.backup(); err != nil {
+		log.Printf("continuing without snapshot: %v", err)
+	}
+	return service.store.OverwriteConfigs(configs)
+}
+
+// Snapshots returns the times of all snapshots, oldest first
+func (service *BackupConfigStore) Snapshots() ([]time.Time, error) {
+	return service.target.Snapshots()
+}
+
+// Restore replaces the sheet with the snapshot after saving a snapshot of the
+// current content, so a restore can be undone
+func (service *BackupConfigStore) Restore(snapshotTime time.Time) error {
+	snapshot, err := service.target.Load(snapshotTime)
+	if err != nil {
+		return err
+	}
+	if err := service.backup(); err != nil {
+		return err
+	}
+
+	writer, err := service.openWriter()
+	if err != nil {
+		return err
+	}
+	log.Printf("restoring %d row(s) of snapshot %s", max(len(snapshot.Data)-1, 0), snapshotTime.UTC().Format(SnapshotLayout))
+	return csv.NewWriter(writer).WriteAll(snapshot.Data)
+}
+
+func (service *BackupConfigStore) backup() error {
+	reader, err := service.openReader()
+	if err != nil {
+		return fmt.Errorf("could not read sheet for backup: %w", err)
+	}
+	data, err := decodeCSV(reader)
+	if err != nil {
+		return fmt.Errorf("could not read sheet for backup: %w", err)
+	}
+
+	snapshot := Snapshot{Time: time.Now().UTC().Truncate(time.Second), Data: data}
+	snapshots, err := service.target.Snapshots()
+	if err != nil {
+		return fmt.Errorf("could not list backups: %w", err)
+	}
+	if len(snapshots) > 0 {
+		latest := snapshots[len(snapshots)-1]
+		if previous, err := service.target.Load(latest); err == nil && slices.EqualFunc(previous.Data, data, slices.Equal) {
+			log.Printf("sheet unchanged since snapshot %s", latest.Format(SnapshotLayout))
+			return nil
+		}
+		// snapshots are named by the second, a second snapshot in the same second
+		// is named after the next free second instead of replacing the first one
+		if !snapshot.Time.After(latest) {
+			snapshot.Time = latest.Add(time.Second)
+		}
+	}
+	if err := service.target.Save(snapshot); err != nil {
+		return fmt.Errorf("could not save backup: %w", err)
+	}
+	log.Printf("saved snapshot %s with %d row(s)", snapshot.Time.Format(SnapshotLayout), max(len(data)-1, 0))
+	return nil
+}
+
+func encodeCSV(data [][]string) ([]byte, error) {
+	buffer := new(bytes.Buffer)
+	if err := csv.NewWriter(buffer).WriteAll(data); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func decodeCSV(reader io.Reader) ([][]string, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+	data, err := csvReader.ReadAll()
+	if data == nil && err == nil {
+		data = make([][]string, 0)
+	}
+	return data, err
+}
@@ -0,0 +1,186 @@
+package configstore
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type memoryTab struct {
+	content string
+}
+
+func (tab *memoryTab) openReader() (io.Reader, error) {
+	return strings.NewReader(tab.content), nil
+}
+
+func (tab *memoryTab) openWriter() (io.Writer, error) {
+	tab.content = ""
+	return tabWriter{tab: tab}, nil
+}
+
+type tabWriter struct {
+	tab *memoryTab
+}
+
+func (writer tabWriter) Write(p []byte) (int, error) {
+	writer.tab.content += string(p)
+	return len(p), nil
+}
+
+type failingBackup struct{}
+
+func (failingBackup) Save(Snapshot) error              { return errors.New("disk full") }
+func (failingBackup) Snapshots() ([]time.Time, error)  { return nil, nil }
+func (failingBackup) Load(time.Time) (Snapshot, error) { return Snapshot{}, errors.New("not found") }
+
+func TestBackupTargets_Retention(t *testing.T) {
+	tab := &memoryTab{}
+	targets := map[string]BackupTarget{
+		"directory": NewDirectoryBackup(t.TempDir(), 2),
+		"sheet":     NewSheetBackup(tab.openReader, tab.openWriter, 2),
+	}
+	start := time.Date(2022, 7, 20, 13, 0, 0, 0, time.UTC)
+	snapshots := []Snapshot{
+		{Time: start, Data: [][]string{{"Message Text"}, {"Test 1"}}},
+		{Time: start.Add(time.Hour), Data: [][]string{}},
+		{Time: start.Add(2 * time.Hour), Data: [][]string{{"Message Text", "Send Date"}, {"Test 2", "22/07/2022"}}},
+	}
+
+	for name, target := range targets {
+		t.Run(name, func(t *testing.T) {
+			for _, snapshot := range snapshots {
+				if err := target.Save(snapshot); err != nil {
+					t.Fatalf("found error %+v", err)
+				}
+			}
+
+			actual, err := target.Snapshots()
+			if err != nil {
+				t.Fatalf("found error %+v", err)
+			}
+			expected := []time.Time{snapshots[1].Time, snapshots[2].Time}
+			if !reflect.DeepEqual(actual, expected) {
+				t.Errorf("expected snapshots %v but got %v", expected, actual)
+			}
+
+			for _, snapshot := range snapshots[1:] {
+				loaded, err := target.Load(snapshot.Time)
+				if err != nil {
+					t.Fatalf("found error %+v", err)
+				}
+				if !reflect.DeepEqual(loaded, snapshot) {
+					t.Errorf("expected snapshot %+v but got %+v", snapshot, loaded)
+				}
+			}
+			if _, err := target.Load(snapshots[0].Time); err == nil {
+				t.Error("expected error for deleted snapshot")
+			}
+		})
+	}
+}
+
+func TestBackupConfigStore_OverwriteConfigs(t *testing.T) {
+	sheet := &memoryTab{content: "Message Text\nTest 1\n"}
+	target := NewDirectoryBackup(t.TempDir(), 0)
+	configs := []ConfigEntry{{CreationTime: time.Now()}}
+	store := &ConfigStoreMock{ReadStore: configs}
+	backupStore := NewBackupConfigStore(store, sheet.openReader, sheet.openWriter, target)
+
+	// reading does not save a snapshot
+	actual, err := backupStore.GetConfigs()
+	if err != nil {
+		t.Fatalf("found error %+v", err)
+	}
+	if !reflect.DeepEqual(actual, configs) {
+		t.Errorf("expected configs %+v but got %+v", configs, actual)
+	}
+	if snapshots, err := target.Snapshots(); err != nil || len(snapshots) != 0 {
+		t.Fatalf("expected no snapshot after reading but got %v (%v)", snapshots, err)
+	}
+
+	// writing an unchanged sheet twice keeps one snapshot, a changed sheet
+	// within the same second is saved as another snapshot
+	for _, content := range []string{"Message Text\nTest 1\n", "Message Text\nTest 1\n", "Message Text\nTest 2\n"} {
+		sheet.content = content
+		if err := backupStore.OverwriteConfigs(configs); err != nil {
+			t.Fatalf("found error %+v", err)
+		}
+	}
+
+	snapshots, err := target.Snapshots()
+	if err != nil || len(snapshots) != 2 {
+		t.Fatalf("expected two snapshots but got %v (%v)", snapshots, err)
+	}
+	snapshot, err := target.Load(snapshots[0])
+	if err != nil {
+		t.Fatalf("found error %+v", err)
+	}
+	if expected := [][]string{{"Message Text"}, {"Test 1"}}; !reflect.DeepEqual(snapshot.Data, expected) {
+		t.Errorf("expected snapshot %v but got %v", expected, snapshot.Data)
+	}
+}
+
+func TestBackupConfigStore_OverwriteConfigs_BackupFails(t *testing.T) {
+	sheet := &memoryTab{content: "Message Text\nTest 1\n"}
+	configs := []ConfigEntry{{CreationTime: time.Now()}}
+	store := &ConfigStoreMock{}
+	backupStore := NewBackupConfigStore(store, sheet.openReader, sheet.openWriter, failingBackup{})
+
+	err := backupStore.OverwriteConfigs(configs)
+	if err != nil {
+		t.Errorf("expected configs to be written without snapshot but got %v", err)
+	}
+	if !reflect.DeepEqual(store.ReadStore, configs) {
+		t.Errorf("expected configs %+v but got %+v", configs, store.ReadStore)
+	}
+}
+
+func TestSheetBackup_SkipsForeignRows(t *testing.T) {
+	tab := &memoryTab{content: "notes,added by hand\n20220720T130000Z,Message Text\n"}
+	target := NewSheetBackup(tab.openReader, tab.openWriter, 1)
+
+	snapshotTime := time.Date(2022, 7, 20, 14, 0, 0, 0, time.UTC)
+	if err := target.Save(Snapshot{Time: snapshotTime, Data: [][]string{{"Message Text"}}}); err != nil {
+		t.Fatalf("found error %+v", err)
+	}
+	snapshots, err := target.Snapshots()
+	if err != nil {
+		t.Fatalf("found error %+v", err)
+	}
+	if expected := []time.Time{snapshotTime}; !reflect.DeepEqual(snapshots, expected) {
+		t.Errorf("expected snapshots %v but got %v", expected, snapshots)
+	}
+	if !strings.HasPrefix(tab.content, "notes,added by hand\n") {
+		t.Errorf("expected foreign row to be kept but got:\n%s", tab.content)
+	}
+}
+
+func TestBackupConfigStore_Restore(t *testing.T) {
+	sheet := &memoryTab{content: "Message Text\nbroken\n"}
+	tab := &memoryTab{}
+	target := NewSheetBackup(tab.openReader, tab.openWriter, 0)
+	snapshotTime := time.Date(2022, 7, 20, 13, 0, 0, 0, time.UTC)
+	if err := target.Save(Snapshot{Time: snapshotTime, Data: [][]string{{"Message Text"}, {"Test 1"}}}); err != nil {
+		t.Fatalf("found error %+v", err)
+	}
+	backupStore := NewBackupConfigStore(&ConfigStoreMock{}, sheet.openReader, sheet.openWriter, target)
+
+	if err := backupStore.Restore(snapshotTime); err != nil {
+		t.Fatalf("found error %+v", err)
+	}
+	if sheet.content != "Message Text\nTest 1\n" {
+		t.Errorf("expected restored sheet but got:\n%s", sheet.content)
+	}
+
+	snapshots, err := backupStore.Snapshots()
+	if err != nil || len(snapshots) != 2 {
+		t.Fatalf("expected a snapshot of the content before the restore but got %v (%v)", snapshots, err)
+	}
+	if !strings.Contains(tab.content, ",broken\n") {
+		t.Errorf("expected content before the restore in backup tab but got:\n%s", tab.content)
+	}
+}
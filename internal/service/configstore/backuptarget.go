@@ -0,0 +1,223 @@
+package configstore
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	snapshotFilePrefix = "snapshot-"
+	snapshotFileSuffix = ".csv"
+)
+
+// DirectoryBackup keeps each snapshot as CSV file in a local directory
+type DirectoryBackup struct {
+	directory string
+	// keep is the number of snapshots to keep, all are kept if zero
+	keep int
+}
+
+func NewDirectoryBackup(directory string, keep int) *DirectoryBackup {
+	return &DirectoryBackup{
+		directory: directory,
+		keep:      keep,
+	}
+}
+
+func (target *DirectoryBackup) Save(snapshot Snapshot) error {
+	content, err := encodeCSV(snapshot.Data)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(target.directory, 0o750); err != nil {
+		return err
+	}
+	if err := os.WriteFile(target.path(snapshot.Time), content, 0o600); err != nil {
+		return err
+	}
+
+	snapshots, err := target.Snapshots()
+	if err != nil {
+		return err
+	}
+	for _, snapshotTime := range expiredSnapshots(snapshots, target.keep) {
+		if err := os.Remove(target.path(snapshotTime)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (target *DirectoryBackup) Snapshots() ([]time.Time, error) {
+	entries, err := os.ReadDir(target.directory)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]time.Time, 0)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, snapshotFilePrefix) || !strings.HasSuffix(name, snapshotFileSuffix) {
+			continue
+		}
+		snapshotTime, err := time.Parse(SnapshotLayout, strings.TrimSuffix(strings.TrimPrefix(name, snapshotFilePrefix), snapshotFileSuffix))
+		if err != nil {
+			continue
+		}
+		result = append(result, snapshotTime)
+	}
+	sortTimes(result)
+	return result, nil
+}
+
+func (target *DirectoryBackup) Load(snapshotTime time.Time) (Snapshot, error) {
+	file, err := os.Open(target.path(snapshotTime))
+	if err != nil {
+		return Snapshot{}, err
+	}
+	defer func() { _ = file.Close() }()
+
+	data, err := decodeCSV(file)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	return Snapshot{Time: snapshotTime, Data: data}, nil
+}
+
+func (target *DirectoryBackup) path(snapshotTime time.Time) string {
+	return filepath.Join(target.directory, snapshotFilePrefix+snapshotTime.UTC().Format(SnapshotLayout)+snapshotFileSuffix)
+}
+
+// SheetBackup keeps all snapshots in a tab of the spreadsheet. Each row starts
+// with the time of its snapshot followed by the cells of the original row.
+type SheetBackup struct {
+	openReader openReader
+	openWriter openWriter
+	// keep is the number of snapshots to keep, all are kept if zero
+	keep int
+}
+
+func NewSheetBackup(openReader openReader, openWriter openWriter, keep int) *SheetBackup {
+	return &SheetBackup{
+		openReader: openReader,
+		openWriter: openWriter,
+		keep:       keep,
+	}
+}
+
+func (target *SheetBackup) Save(snapshot Snapshot) error {
+	data, err := target.read()
+	if err != nil {
+		return err
+	}
+
+	name := snapshot.Time.UTC().Format(SnapshotLayout)
+	// an empty snapshot is marked by a row with its time only
+	if len(snapshot.Data) == 0 {
+		data = append(data, []string{name})
+	}
+	for _, row := range snapshot.Data {
+		data = append(data, append([]string{name}, row...))
+	}
+
+	expired := make(map[string]bool)
+	for _, snapshotTime := range expiredSnapshots(snapshotsOf(data), target.keep) {
+		expired[snapshotTime.Format(SnapshotLayout)] = true
+	}
+	result := make([][]string, 0, len(data))
+	for _, row := range data {
+		if !expired[row[0]] {
+			result = append(result, row)
+		}
+	}
+
+	writer, err := target.openWriter()
+	if err != nil {
+		return err
+	}
+	content, err := encodeCSV(result)
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(content)
+	return err
+}
+
+func (target *SheetBackup) Snapshots() ([]time.Time, error) {
+	data, err := target.read()
+	if err != nil {
+		return nil, err
+	}
+	return snapshotsOf(data), nil
+}
+
+func (target *SheetBackup) Load(snapshotTime time.Time) (Snapshot, error) {
+	data, err := target.read()
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	name := snapshotTime.UTC().Format(SnapshotLayout)
+	found := false
+	result := Snapshot{Time: snapshotTime, Data: make([][]string, 0)}
+	for _, row := range data {
+		if row[0] != name {
+			continue
+		}
+		found = true
+		if len(row) > 1 {
+			result.Data = append(result.Data, row[1:])
+		}
+	}
+	if !found {
+		return Snapshot{}, fmt.Errorf("snapshot %s not found", name)
+	}
+	return result, nil
+}
+
+func (target *SheetBackup) read() ([][]string, error) {
+	reader, err := target.openReader()
+	if err != nil {
+		return nil, err
+	}
+	return decodeCSV(reader)
+}
+
+// snapshotsOf returns the distinct snapshot times in the first column, rows
+// without a snapshot time, e.g. added by hand, are skipped
+func snapshotsOf(data [][]string) []time.Time {
+	seen := make(map[string]bool)
+	result := make([]time.Time, 0)
+	for _, row := range data {
+		if seen[row[0]] {
+			continue
+		}
+		seen[row[0]] = true
+		snapshotTime, err := time.Parse(SnapshotLayout, row[0])
+		if err != nil {
+			continue
+		}
+		result = append(result, snapshotTime)
+	}
+	sortTimes(result)
+	return result
+}
+
+// expiredSnapshots returns the oldest snapshots above the number to keep
+func expiredSnapshots(snapshots []time.Time, keep int) []time.Time {
+	if keep <= 0 || len(snapshots) <= keep {
+		return nil
+	}
+	return snapshots[:len(snapshots)-keep]
+}
+
+func sortTimes(times []time.Time) {
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+}
@@ -0,0 +1,119 @@
+package configstore
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	timestampColumn   = "Timestamp"
+	messageTextColumn = "Message Text"
+	sendDateColumn    = "Send Date"
+	sendTimeColumn    = "Send Time"
+	phoneNumberColumn = "Phone Number"
+	mailAddressColumn = "Mail Address"
+	processTimeColumn = "Process Time"
+	timeZoneColumn    = "Time Zone"
+)
+
+// header is written if the sheet was not read before
+var header = []string{timestampColumn, messageTextColumn, sendDateColumn, sendTimeColumn, phoneNumberColumn, mailAddressColumn, processTimeColumn}
+
+// requiredColumns have to exist in the sheet, all other columns are optional
+var requiredColumns = []string{messageTextColumn, sendDateColumn, sendTimeColumn}
+
+// Columns locates the known columns of the sheet by their header. Headers are
+// compared case-insensitively and can have aliases, e.g. translations.
+type Columns struct {
+	// names maps normalized headers to the name of the known column
+	names map[string]string
+}
+
+// DefaultColumns only knows the columns by their names
+func DefaultColumns() Columns {
+	columns, _ := NewColumns(nil)
+	return columns
+}
+
+// NewColumns creates the column mapping with aliases by column name,
+// e.g. {"Send Date": ["Datum"]}
+func NewColumns(aliases map[string][]string) (Columns, error) {
+	result := Columns{names: make(map[string]string)}
+	for _, name := range knownColumns() {
+		result.names[normalizeHeader(name)] = name
+	}
+
+	for name, names := range aliases {
+		if !result.isKnown(name) {
+			return Columns{}, fmt.Errorf("unknown column '%s', known columns are: %s", name, strings.Join(knownColumns(), ", "))
+		}
+		for _, alias := range names {
+			if existing, ok := result.names[normalizeHeader(alias)]; ok && existing != name {
+				return Columns{}, fmt.Errorf("alias '%s' of column '%s' already refers to column '%s'", alias, name, existing)
+			}
+			result.names[normalizeHeader(alias)] = name
+		}
+	}
+	return result, nil
+}
+
+// name returns the name of the known column with the given header
+func (columns Columns) name(header string) (string, bool) {
+	name, ok := columns.names[normalizeHeader(header)]
+	return name, ok
+}
+
+func (columns Columns) isKnown(name string) bool {
+	for _, column := range knownColumns() {
+		if column == name {
+			return true
+		}
+	}
+	return false
+}
+
+// locate returns the index of each known column in the header row,
+// the first column wins if a column appears twice
+func (columns Columns) locate(headers []string) map[string]int {
+	result := make(map[string]int)
+	for j, header := range headers {
+		if name, ok := columns.name(header); ok {
+			if _, exists := result[name]; !exists {
+				result[name] = j
+			}
+		}
+	}
+	return result
+}
+
+func knownColumns() []string {
+	result := append([]string{}, header...)
+	for _, column := range optionalColumns {
+		result = append(result, column.name)
+	}
+	return append(result, errorColumn)
+}
+
+func normalizeHeader(header string) string {
+	return strings.ToLower(strings.TrimSpace(header))
+}
+
+// sheetRow provides the cells of a row by the name of their column
+type sheetRow struct {
+	cells   []string
+	indexes map[string]int
+}
+
+func (row sheetRow) has(name string) bool {
+	_, ok := row.indexes[name]
+	return ok
+}
+
+// get returns an empty string for missing columns and cells
+func (row sheetRow) get(name string) string {
+	j, ok := row.indexes[name]
+	if !ok || j >= len(row.cells) {
+		return ""
+	}
+	return row.cells[j]
+}
@@ -1,17 +1,91 @@
 package configstore
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/jo-hoe/whatsapp-reminder/internal/dto"
 )
 
+const (
+	// StatusSkipped marks entries which were not sent because they were overdue for too long
+	StatusSkipped = "skipped"
+	// StatusEscalated marks entries whose secondary recipients were notified
+	// because the reminder was not acknowledged
+	StatusEscalated = "escalated"
+	// StatusInvalid prefixes the reason why an unprocessed entry cannot be sent,
+	// e.g. a phone number in national format without default country code
+	StatusInvalid = "invalid"
+)
+
 type ConfigEntry struct {
 	WhatsappReminderConfig dto.WhatsappReminderConfig
 	CreationTime           time.Time
 	DueTime                time.Time
 	ProcessTime            time.Time
+	// Variables contains the values of additional columns by their header
+	Variables map[string]string
+	// LeadTime lists durations before the due time to send advance notices, e.g. "7d,1d"
+	LeadTime string
+	// NoticesSent lists the lead times for which an advance notice was sent
+	NoticesSent []string
+	// BusinessDayRule names the rule to roll the due time forward to a business day, e.g. "weekends" or "DE"
+	BusinessDayRule string
+	// Status describes how a processed entry was handled, empty if it was sent
+	Status string
+	// AcknowledgedTime is set when a recipient marked the reminder as done
+	AcknowledgedTime time.Time
+	// Escalate enables escalation if not acknowledged, e.g. "yes" or "no"
+	Escalate string
+	// Resends counts how often the reminder was sent again because it was not acknowledged
+	Resends int
+	// TimeZone is the IANA name of the time zone of the due time, e.g. "America/New_York"
+	TimeZone string
+}
+
+// Location returns the time zone of the entry or the default location if none is set
+func (entry ConfigEntry) Location(defaultLocation *time.Location) (*time.Location, error) {
+	if entry.TimeZone == "" {
+		return defaultLocation, nil
+	}
+	location, err := time.LoadLocation(entry.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone '%s': %w", entry.TimeZone, err)
+	}
+	return location, nil
+}
+
+// ID identifies an entry by its timestamp, phone number and message. It does
+// not depend on the due time, so links to an entry keep working after it was
+// snoozed or rescheduled.
+func (entry ConfigEntry) ID() string {
+	hash := sha256.Sum256([]byte(strings.Join([]string{
+		strconv.FormatInt(entry.CreationTime.Unix(), 10),
+		entry.WhatsappReminderConfig.PhoneNumber,
+		entry.WhatsappReminderConfig.MessageText,
+	}, "\x00")))
+	return hex.EncodeToString(hash[:12])
+}
+
+// MatchesID returns whether the given ID belongs to the entry. Links sent by
+// earlier versions carry an ID which also contains the due time, they keep
+// working as long as the entry was not rescheduled.
+func (entry ConfigEntry) MatchesID(id string) bool {
+	if id == entry.ID() {
+		return true
+	}
+	hash := sha256.Sum256([]byte(strings.Join([]string{
+		strconv.FormatInt(entry.CreationTime.Unix(), 10),
+		strconv.FormatInt(entry.DueTime.Unix(), 10),
+		entry.WhatsappReminderConfig.PhoneNumber,
+		entry.WhatsappReminderConfig.MessageText,
+	}, "\x00")))
+	return id == hex.EncodeToString(hash[:12])
 }
 
 type ConfigStore interface {
@@ -2,12 +2,17 @@ package configstore
 
 import (
 	"encoding/csv"
+	"fmt"
 	"io"
 	"log"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/jo-hoe/whatsapp-reminder/internal/dto"
+	"github.com/jo-hoe/whatsapp-reminder/internal/timeformat"
 )
 
 type CSVConfigStore struct {
@@ -15,18 +20,161 @@ type CSVConfigStore struct {
 	openReader      openReader
 	openWriter      openWriter
 	defaultLocation time.Location
+	format          timeformat.Format
+	columns         Columns
+	// errorTab receives invalid rows, if nil they are kept in the sheet
+	errorTab  *ErrorTab
+	writeMode WriteMode
+	// fillTimestamp sets the timestamp of rows without one to the time they
+	// were read, e.g. rows typed into a form response sheet
+	fillTimestamp bool
+	// headers keeps the header row of the last read to write columns in the same order
+	headers []string
+	// invalidRows keeps the rows of the last read which could not be read
+	invalidRows []invalidRow
+	// data and readRows keep the last read to merge changes into the sheet
+	data     [][]string
+	readRows []readRow
+}
+
+// readRow is an entry with the index of its row in the data of the last read
+type readRow struct {
+	index int
+	entry ConfigEntry
+	// filledTimestamp is set if the timestamp was empty and filled on read
+	filledTimestamp bool
 }
 
 type openReader func() (reader io.Reader, err error)
 type openWriter func() (writer io.Writer, err error)
 
-var header = []string{"Timestamp", "Message Text", "Send Date", "Send Time", "Phone Number", "Mail Address", "Process Time"}
+// rowFormat reads and writes the times of a row in its time zone
+type rowFormat struct {
+	format   timeformat.Format
+	location *time.Location
+}
+
+func (row rowFormat) parseOptionalTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return row.format.ParseDateTime(value, row.location)
+}
+
+func (row rowFormat) formatOptionalTime(value time.Time) string {
+	if value.IsZero() {
+		return ""
+	}
+	return row.format.FormatDateTime(value.In(row.location))
+}
+
+// optionalColumn is an additional column with a dedicated field in ConfigEntry.
+// It is only written if it existed on read or if any entry has a value for it.
+type optionalColumn struct {
+	name  string
+	read  func(entry *ConfigEntry, value string, row rowFormat) error
+	write func(entry ConfigEntry, row rowFormat) string
+}
+
+var optionalColumns = []optionalColumn{
+	{
+		name: "Lead Time",
+		read: func(entry *ConfigEntry, value string, _ rowFormat) error {
+			entry.LeadTime = value
+			return nil
+		},
+		write: func(entry ConfigEntry, _ rowFormat) string { return entry.LeadTime },
+	}, {
+		name: "Notices Sent",
+		read: func(entry *ConfigEntry, value string, _ rowFormat) error {
+			entry.NoticesSent = splitList(value)
+			return nil
+		},
+		write: func(entry ConfigEntry, _ rowFormat) string { return strings.Join(entry.NoticesSent, ",") },
+	}, {
+		name: "Business Day Rule",
+		read: func(entry *ConfigEntry, value string, _ rowFormat) error {
+			entry.BusinessDayRule = value
+			return nil
+		},
+		write: func(entry ConfigEntry, _ rowFormat) string { return entry.BusinessDayRule },
+	}, {
+		name: statusColumn,
+		read: func(entry *ConfigEntry, value string, _ rowFormat) error {
+			entry.Status = value
+			return nil
+		},
+		write: func(entry ConfigEntry, _ rowFormat) string { return entry.Status },
+	}, {
+		name: "Acknowledged Time",
+		// an unreadable time does not hold the row, it is not acknowledged then
+		read: func(entry *ConfigEntry, value string, row rowFormat) (err error) {
+			if entry.AcknowledgedTime, err = row.parseOptionalTime(value); err != nil {
+				log.Printf("treating reminder as not acknowledged, invalid acknowledged time '%s': %v", value, err)
+			}
+			return nil
+		},
+		write: func(entry ConfigEntry, row rowFormat) string { return row.formatOptionalTime(entry.AcknowledgedTime) },
+	}, {
+		name: "Escalate",
+		read: func(entry *ConfigEntry, value string, _ rowFormat) error {
+			entry.Escalate = value
+			return nil
+		},
+		write: func(entry ConfigEntry, _ rowFormat) string { return entry.Escalate },
+	}, {
+		name: "Resends",
+		read: func(entry *ConfigEntry, value string, _ rowFormat) (err error) {
+			if value == "" {
+				return nil
+			}
+			entry.Resends, err = strconv.Atoi(value)
+			return err
+		},
+		write: func(entry ConfigEntry, _ rowFormat) string {
+			if entry.Resends == 0 {
+				return ""
+			}
+			return strconv.Itoa(entry.Resends)
+		},
+	}, {
+		name: timeZoneColumn,
+		read: func(entry *ConfigEntry, value string, _ rowFormat) error {
+			entry.TimeZone = value
+			return nil
+		},
+		write: func(entry ConfigEntry, _ rowFormat) string { return entry.TimeZone },
+	},
+}
 
-func NewCSVConfigStore(openReader openReader, openWriter openWriter, defaultLocation time.Location) *CSVConfigStore {
+// CSVConfigStoreOptions are the optional settings of the store
+type CSVConfigStoreOptions struct {
+	// Columns maps the headers of the sheet to the known columns, defaults to DefaultColumns
+	Columns Columns
+	// ErrorTab receives rows which could not be read instead of keeping them in the sheet
+	ErrorTab *ErrorTab
+	// WriteMode defaults to overwrite
+	WriteMode WriteMode
+	// FillTimestamp sets the timestamp of rows without one to the time they were read
+	FillTimestamp bool
+}
+
+func NewCSVConfigStore(openReader openReader, openWriter openWriter, defaultLocation time.Location, format timeformat.Format, options CSVConfigStoreOptions) *CSVConfigStore {
+	if options.Columns.names == nil {
+		options.Columns = DefaultColumns()
+	}
+	if options.WriteMode == "" {
+		options.WriteMode = WriteModeOverwrite
+	}
 	return &CSVConfigStore{
 		openReader:      openReader,
 		openWriter:      openWriter,
 		defaultLocation: defaultLocation,
+		format:          format,
+		columns:         options.Columns,
+		errorTab:        options.ErrorTab,
+		writeMode:       options.WriteMode,
+		fillTimestamp:   options.FillTimestamp,
 	}
 }
 
@@ -34,98 +182,329 @@ func (service *CSVConfigStore) OverwriteConfigs(configs []ConfigEntry) error {
 	service.mutex.Lock()
 	defer service.mutex.Unlock()
 
+	if service.writeMode == WriteModeMerge {
+		return service.mergeConfigs(configs)
+	}
+
+	service.moveInvalidRows()
+
 	writer, err := service.openWriter()
 	if err != nil {
 		return err
 	}
 
+	headers := service.getHeaders(configs)
+
 	csvWriter := csv.NewWriter(writer)
-	err = csvWriter.Write(header)
+	err = csvWriter.Write(headers)
 	if err != nil {
 		return err
 	}
 
 	data := make([][]string, 0)
+	indexes := service.columns.locate(headers)
+	used := make([]bool, len(service.readRows))
 	for _, config := range configs {
-		row := make([]string, 7)
-		row[0] = config.CreationTime.Format("02/01/2006 15:04:05")
-		row[1] = config.WhatsappReminderConfig.MessageText
-		row[2] = config.DueTime.Format("02/01/2006")
-		row[3] = config.DueTime.Format("15:04:05")
-		row[4] = config.WhatsappReminderConfig.PhoneNumber
-		row[5] = config.WhatsappReminderConfig.MailAddress
-		if config.ProcessTime.IsZero() {
-			row[6] = ""
-		} else {
-			row[6] = config.ProcessTime.Format("02/01/2006 15:04:05")
+		// due and process time are written in the time zone of the row
+		location, err := config.Location(&service.defaultLocation)
+		if err != nil {
+			return err
+		}
+		formatter := rowFormat{format: service.format, location: location}
+
+		var cells []string
+		if k := service.findReadRow(config, used); k >= 0 {
+			used[k] = true
+			cells = service.data[service.readRows[k].index]
 		}
+		row := make([]string, len(headers))
+		for j, header := range headers {
+			row[j] = service.writeColumn(config, header, j, indexes, cells, formatter)
+		}
+		data = append(data, row)
+	}
 
+	// invalid rows are kept verbatim with the reason in the error column
+	errorIndex := indexes[errorColumn]
+	for _, invalidRow := range service.invalidRows {
+		row := make([]string, max(len(headers), len(invalidRow.cells)))
+		copy(row, invalidRow.cells)
+		row[errorIndex] = invalidRow.reason
 		data = append(data, row)
 	}
 
 	return csvWriter.WriteAll(data)
 }
 
+// moveInvalidRows moves the invalid rows to the error tab if configured and
+// returns the moved rows. If this fails, they are kept in the sheet.
+func (service *CSVConfigStore) moveInvalidRows() []invalidRow {
+	if service.errorTab == nil || len(service.invalidRows) == 0 {
+		return nil
+	}
+	errorIndex, ok := service.columns.locate(service.headers)[errorColumn]
+	if !ok {
+		errorIndex = -1
+	}
+	if err := service.errorTab.append(service.headers, errorIndex, service.invalidRows); err != nil {
+		log.Printf("could not move %d invalid row(s) to the error tab, keeping them in the sheet: %v", len(service.invalidRows), err)
+		return nil
+	}
+	log.Printf("moved %d invalid row(s) to the error tab", len(service.invalidRows))
+	moved := service.invalidRows
+	service.invalidRows = nil
+	return moved
+}
+
 func (service *CSVConfigStore) GetConfigs() ([]ConfigEntry, error) {
-	service.mutex.RLock()
-	defer service.mutex.RUnlock()
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
 
-	reader, err := service.openReader()
+	data, err := service.readData()
 	if err != nil {
 		return nil, err
 	}
 
 	result := make([]ConfigEntry, 0)
+	service.invalidRows = nil
+	service.readRows = nil
+	service.data = data
+	if len(data) == 0 {
+		service.headers = nil
+		return result, nil
+	}
+
+	service.headers = append([]string{}, data[0]...)
+	indexes := service.columns.locate(data[0])
+	for _, name := range requiredColumns {
+		if _, ok := indexes[name]; !ok {
+			return nil, fmt.Errorf("missing column '%s' in header %v", name, data[0])
+		}
+	}
+
+	// 'i' starts a 1 to skip the csv header
+	for i := 1; i < len(data); i++ {
+		row := sheetRow{cells: data[i], indexes: indexes}
+		item, err := service.readEntry(row, data[0])
+		if err != nil {
+			service.invalidRows = append(service.invalidRows, invalidRow{number: i + 1, cells: data[i], reason: err.Error()})
+			continue
+		}
+		filled := service.fillTimestamp && strings.TrimSpace(row.get(timestampColumn)) == ""
+		service.readRows = append(service.readRows, readRow{index: i, entry: item, filledTimestamp: filled})
+		result = append(result, item)
+	}
+	logInvalidRows(service.invalidRows)
+
+	return result, nil
+}
+
+func (service *CSVConfigStore) readData() ([][]string, error) {
+	reader, err := service.openReader()
+	if err != nil {
+		return nil, err
+	}
+
 	csvReader := csv.NewReader(reader)
 	// deactivate field length validation
 	csvReader.FieldsPerRecord = -1
-	data, err := csvReader.ReadAll()
+	return csvReader.ReadAll()
+}
+
+func logInvalidRows(rows []invalidRow) {
+	if len(rows) == 0 {
+		return
+	}
+	log.Printf("%d row(s) could not be read and are not processed:", len(rows))
+	for _, row := range rows {
+		log.Printf("  row %d: %s", row.number, row.reason)
+	}
+}
+
+func (service *CSVConfigStore) readEntry(row sheetRow, headers []string) (ConfigEntry, error) {
+	creationTime := time.Time{}
+	if service.fillTimestamp && strings.TrimSpace(row.get(timestampColumn)) == "" {
+		creationTime = time.Now().In(&service.defaultLocation).Truncate(time.Second)
+	} else if row.has(timestampColumn) {
+		var err error
+		if creationTime, err = service.format.ParseDateTime(row.get(timestampColumn), &service.defaultLocation); err != nil {
+			return ConfigEntry{}, err
+		}
+	}
+	// send date, send time and process time are in the time zone of the row if set
+	location, err := ConfigEntry{TimeZone: row.get(timeZoneColumn)}.Location(&service.defaultLocation)
 	if err != nil {
-		return nil, err
+		return ConfigEntry{}, err
+	}
+	formatter := rowFormat{format: service.format, location: location}
+	processTime, err := formatter.parseOptionalTime(row.get(processTimeColumn))
+	if err != nil {
+		return ConfigEntry{}, err
+	}
+	dueTime, err := service.format.ParseDateAndTime(row.get(sendDateColumn), row.get(sendTimeColumn), location)
+	if err != nil {
+		return ConfigEntry{}, err
 	}
 
-	// 'i' starts a 1 to skip the csv header
-	for i := 1; i < len(data); i++ {
-		creationTime, err := time.ParseInLocation("02/01/2006 15:04:05", getString(data, i, 0), &service.defaultLocation)
-		if err != nil {
-			log.Printf("could parse time '%v' in %+v", getString(data, i, 0), data[i])
+	item := ConfigEntry{
+		CreationTime: creationTime,
+		DueTime:      dueTime,
+		ProcessTime:  processTime,
+		WhatsappReminderConfig: dto.WhatsappReminderConfig{
+			MessageText: row.get(messageTextColumn),
+			PhoneNumber: row.get(phoneNumberColumn),
+			MailAddress: row.get(mailAddressColumn),
+		},
+		Variables: service.readVariables(row.cells, headers),
+	}
+	for _, column := range optionalColumns {
+		if !row.has(column.name) {
 			continue
 		}
-		processTime := time.Time{}
-		if len(getString(data, i, 6)) != 0 {
-			processTime, err = time.ParseInLocation("02/01/2006 15:04:05", getString(data, i, 6), &service.defaultLocation)
-			if err != nil {
-				log.Printf("could parse time '%v' in %+v", getString(data, i, 6), data[i])
-				continue
-			}
+		if err := column.read(&item, row.get(column.name), formatter); err != nil {
+			return ConfigEntry{}, fmt.Errorf("invalid value in column '%s': %w", column.name, err)
 		}
-		dueTime, err := time.ParseInLocation("02/01/2006 15:04:05", getString(data, i, 2)+" "+getString(data, i, 3), &service.defaultLocation)
-		if err != nil {
-			log.Printf("could parse time '%v' in %+v", getString(data, i, 2)+" "+getString(data, i, 3), data[i])
+	}
+	return item, nil
+}
+
+// readVariables returns the values of all columns which are not known,
+// the first column wins if a header appears twice
+func (service *CSVConfigStore) readVariables(cells []string, headers []string) map[string]string {
+	var result map[string]string
+	for j, header := range headers {
+		if _, ok := service.columns.name(header); ok || header == "" {
+			continue
+		}
+		if result == nil {
+			result = make(map[string]string)
+		}
+		if _, ok := result[header]; ok {
 			continue
 		}
+		result[header] = ""
+		if j < len(cells) {
+			result[header] = cells[j]
+		}
+	}
+	return result
+}
 
-		item := ConfigEntry{
-			CreationTime: creationTime,
-			DueTime:      dueTime,
-			ProcessTime:  processTime,
-			WhatsappReminderConfig: dto.WhatsappReminderConfig{
-				MessageText: getString(data, i, 1),
-				PhoneNumber: getString(data, i, 4),
-				MailAddress: getString(data, i, 5),
-			},
+// writeColumn returns the value of the column at index j. Columns which are
+// not known, e.g. without or with a duplicate header, are written back from
+// the cells of the row as read, cells is nil for entries which were not read.
+func (service *CSVConfigStore) writeColumn(entry ConfigEntry, header string, j int, indexes map[string]int, cells []string, row rowFormat) string {
+	name, known := service.columns.name(header)
+	if known && indexes[name] == j {
+		return service.writeCell(entry, header, row)
+	}
+	if j < len(service.headers) {
+		if j < len(cells) {
+			return cells[j]
+		}
+		if cells != nil || known || header == "" {
+			return ""
 		}
-		result = append(result, item)
 	}
+	return service.writeCell(entry, header, row)
+}
 
-	return result, nil
+// writeCell returns the value of the column with the given header
+func (service *CSVConfigStore) writeCell(entry ConfigEntry, header string, row rowFormat) string {
+	name, ok := service.columns.name(header)
+	if !ok {
+		return entry.Variables[header]
+	}
+
+	switch name {
+	case timestampColumn:
+		return rowFormat{format: service.format, location: &service.defaultLocation}.formatOptionalTime(entry.CreationTime)
+	case messageTextColumn:
+		return entry.WhatsappReminderConfig.MessageText
+	case sendDateColumn:
+		return service.format.FormatDate(entry.DueTime.In(row.location))
+	case sendTimeColumn:
+		return service.format.FormatTime(entry.DueTime.In(row.location))
+	case phoneNumberColumn:
+		return entry.WhatsappReminderConfig.PhoneNumber
+	case mailAddressColumn:
+		return entry.WhatsappReminderConfig.MailAddress
+	case processTimeColumn:
+		return row.formatOptionalTime(entry.ProcessTime)
+	case errorColumn:
+		return ""
+	}
+	return findOptionalColumn(name).write(entry, row)
+}
+
+func findOptionalColumn(name string) *optionalColumn {
+	for i := range optionalColumns {
+		if optionalColumns[i].name == name {
+			return &optionalColumns[i]
+		}
+	}
+	return nil
+}
+
+// getHeaders keeps the header row of the last read, then appends missing known
+// columns with values and variables only known by the given configs
+func (service *CSVConfigStore) getHeaders(configs []ConfigEntry) []string {
+	if service.headers == nil {
+		return service.appendHeaders(append([]string{}, header...), configs)
+	}
+	return service.appendHeaders(append([]string{}, service.headers...), configs)
+}
+
+func (service *CSVConfigStore) appendHeaders(result []string, configs []ConfigEntry) []string {
+	known := make(map[string]bool)
+	for _, header := range result {
+		if name, ok := service.columns.name(header); ok {
+			known[name] = true
+		} else {
+			known[header] = true
+		}
+	}
+
+	formatter := rowFormat{format: service.format, location: &service.defaultLocation}
+	for _, name := range knownColumns() {
+		if known[name] {
+			continue
+		}
+		for _, config := range configs {
+			if service.writeCell(config, name, formatter) != "" {
+				known[name] = true
+				result = append(result, name)
+				break
+			}
+		}
+	}
+
+	if len(service.invalidRows) > 0 && !known[errorColumn] {
+		known[errorColumn] = true
+		result = append(result, errorColumn)
+	}
+
+	additional := make([]string, 0)
+	for _, config := range configs {
+		for column := range config.Variables {
+			if !known[column] {
+				known[column] = true
+				additional = append(additional, column)
+			}
+		}
+	}
+	sort.Strings(additional)
+
+	return append(result, additional...)
 }
 
-func getString(data [][]string, i int, j int) string {
-	if len(data)-1 >= i {
-		if len(data[i])-1 >= j {
-			return data[i][j]
+// splitList splits comma separated values and drops empty ones
+func splitList(value string) []string {
+	var result []string
+	for _, item := range strings.Split(value, ",") {
+		if trimmed := strings.TrimSpace(item); trimmed != "" {
+			result = append(result, trimmed)
 		}
 	}
-	return ""
+	return result
 }
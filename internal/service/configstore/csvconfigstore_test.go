@@ -2,14 +2,17 @@ package configstore
 
 import (
 	"bytes"
+	"errors"
 	"io"
 	"os"
 	"reflect"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/jo-hoe/whatsapp-reminder/internal/dto"
+	"github.com/jo-hoe/whatsapp-reminder/internal/timeformat"
 )
 
 const testFileName = "testdata/test.csv"
@@ -17,7 +20,7 @@ const testFileName = "testdata/test.csv"
 func TestCSVConfigStore_GetConfigs(t *testing.T) {
 	expected := getTestConfig(t)
 
-	configStore := NewCSVConfigStore(openTestReader, nil, *getDefaultTestLocation(t))
+	configStore := NewCSVConfigStore(openTestReader, nil, *getDefaultTestLocation(t), timeformat.Default(), CSVConfigStoreOptions{})
 
 	actual, err := configStore.GetConfigs()
 
@@ -40,7 +43,7 @@ func TestCSVConfigStore_OverwriteConfigs(t *testing.T) {
 		return file, err
 	}
 
-	configStore := NewCSVConfigStore(nil, openWriter, *getDefaultTestLocation(t))
+	configStore := NewCSVConfigStore(nil, openWriter, *getDefaultTestLocation(t), timeformat.Default(), CSVConfigStoreOptions{})
 
 	err = configStore.OverwriteConfigs(getTestConfig(t))
 	if err != nil {
@@ -59,6 +62,45 @@ func TestCSVConfigStore_OverwriteConfigs(t *testing.T) {
 	}
 }
 
+func TestCSVConfigStore_AdditionalColumns_RoundTrip(t *testing.T) {
+	const variablesFileName = "testdata/variables.csv"
+	openReader := func() (reader io.Reader, err error) {
+		return os.Open(variablesFileName)
+	}
+	file, err := os.CreateTemp("", "tempfile-")
+	if err != nil {
+		t.Errorf("found error %+v", err)
+	}
+	openWriter := func() (writer io.Writer, err error) {
+		return file, err
+	}
+
+	configStore := NewCSVConfigStore(openReader, openWriter, *getDefaultTestLocation(t), timeformat.Default(), CSVConfigStoreOptions{})
+
+	configs, err := configStore.GetConfigs()
+	if err != nil {
+		t.Errorf("found error %+v", err)
+	}
+	expectedVariables := map[string]string{"Name": "Anna", "Birth Date": "22/07/1990"}
+	if len(configs) != 2 || !reflect.DeepEqual(configs[0].Variables, expectedVariables) {
+		t.Errorf("expected variables %v but got %+v", expectedVariables, configs)
+	}
+	if configs[0].LeadTime != "7d,1d" || !reflect.DeepEqual(configs[0].NoticesSent, []string{"7d"}) {
+		t.Errorf("expected lead time and sent notices but got %+v", configs[0])
+	}
+
+	err = configStore.OverwriteConfigs(configs)
+	if err != nil {
+		t.Errorf("found error %+v", err)
+	}
+
+	actual := strings.ReplaceAll(getFileContent(t, file.Name()), "\r", "")
+	expected := strings.ReplaceAll(getFileContent(t, variablesFileName), "\r", "")
+	if actual != expected {
+		t.Errorf("actual:\n%s\nnot equal to expected:\n%s", actual, expected)
+	}
+}
+
 func openTestReader() (reader io.Reader, err error) {
 	return os.Open(testFileName)
 }
@@ -116,3 +158,605 @@ func getFileContent(t *testing.T, filepath string) string {
 
 	return buffer.String()
 }
+
+func TestCSVConfigStore_OverwriteConfigs_OptionalColumns(t *testing.T) {
+	file, err := os.CreateTemp("", "tempfile-")
+	if err != nil {
+		t.Errorf("found error %+v", err)
+	}
+	openWriter := func() (writer io.Writer, err error) {
+		return file, err
+	}
+	configStore := NewCSVConfigStore(nil, openWriter, *getDefaultTestLocation(t), timeformat.Default(), CSVConfigStoreOptions{})
+
+	configs := getTestConfig(t)
+	configs[1].NoticesSent = []string{"7d", "1d"}
+	err = configStore.OverwriteConfigs(configs)
+	if err != nil {
+		t.Errorf("found error %+v", err)
+	}
+
+	actual := getFileContent(t, file.Name())
+	if !strings.HasPrefix(actual, strings.Join(header, ",")+",Notices Sent\n") {
+		t.Errorf("expected only used optional column in header but got:\n%s", actual)
+	}
+	if !strings.Contains(actual, ",\"7d,1d\"\n") {
+		t.Errorf("expected sent notices in output but got:\n%s", actual)
+	}
+}
+
+func TestCSVConfigStore_InvalidAcknowledgedTime_NotAcknowledged(t *testing.T) {
+	input := strings.Join(header, ",") + ",Acknowledged Time\n" +
+		"20/07/2022 13:13:13,Test 1,22/07/2022,09:00:00,01234567890,,22/07/2022 09:05:00,yesterday\n"
+	configStore := NewCSVConfigStore(func() (io.Reader, error) { return strings.NewReader(input), nil }, nil,
+		*getDefaultTestLocation(t), timeformat.Default(), CSVConfigStoreOptions{})
+
+	configs, err := configStore.GetConfigs()
+	if err != nil || len(configs) != 1 {
+		t.Fatalf("expected the row to be read but got %+v, %v", configs, err)
+	}
+	if !configs[0].AcknowledgedTime.IsZero() {
+		t.Errorf("expected the row not to be acknowledged but got %v", configs[0].AcknowledgedTime)
+	}
+}
+
+func TestCSVConfigStore_UnknownColumns_RoundTripByIndex(t *testing.T) {
+	input := "Timestamp,Message Text,Send Date,Send Time,,Note,Note,Message Text\n" +
+		"20/07/2022 13:13:13,Test 1,22/07/2022,09:00:00,untitled,first,second,copy\n" +
+		"20/07/2022 13:13:13,Test 2,22/07/2022,10:00:00,,,,\n"
+	buffer := new(bytes.Buffer)
+	openWriter := func() (writer io.Writer, err error) {
+		return buffer, nil
+	}
+	configStore := NewCSVConfigStore(func() (io.Reader, error) { return strings.NewReader(input), nil }, openWriter,
+		*getDefaultTestLocation(t), timeformat.Default(), CSVConfigStoreOptions{})
+
+	configs, err := configStore.GetConfigs()
+	if err != nil || len(configs) != 2 {
+		t.Fatalf("expected two entries but got %+v, %v", configs, err)
+	}
+	if expected := map[string]string{"Note": "first"}; !reflect.DeepEqual(configs[0].Variables, expected) {
+		t.Errorf("expected variables %v but got %v", expected, configs[0].Variables)
+	}
+
+	// the entries are written in a different order
+	if err := configStore.OverwriteConfigs([]ConfigEntry{configs[1], configs[0]}); err != nil {
+		t.Fatalf("found error %+v", err)
+	}
+	expected := "Timestamp,Message Text,Send Date,Send Time,,Note,Note,Message Text\n" +
+		"20/07/2022 13:13:13,Test 2,22/07/2022,10:00:00,,,,\n" +
+		"20/07/2022 13:13:13,Test 1,22/07/2022,09:00:00,untitled,first,second,copy\n"
+	if buffer.String() != expected {
+		t.Errorf("actual:\n%s\nnot equal to expected:\n%s", buffer.String(), expected)
+	}
+}
+
+func TestCSVConfigStore_TimeZone(t *testing.T) {
+	input := strings.Join(header, ",") + ",Time Zone\n" +
+		"20/07/2022 13:13:13,Test 1,22/07/2022,09:00:00,01234567890,,,America/New_York\n" +
+		"20/07/2022 13:13:13,Test 2,22/07/2022,09:00:00,01234567890,,,Mars/Olympus_Mons\n" +
+		"20/07/2022 13:13:13,Test 3,22/07/2022,09:00:00,01234567890,,,\n"
+	openReader := func() (reader io.Reader, err error) {
+		return strings.NewReader(input), nil
+	}
+	buffer := new(bytes.Buffer)
+	openWriter := func() (writer io.Writer, err error) {
+		return buffer, nil
+	}
+	configStore := NewCSVConfigStore(openReader, openWriter, *getDefaultTestLocation(t), timeformat.Default(), CSVConfigStoreOptions{})
+
+	configs, err := configStore.GetConfigs()
+	if err != nil {
+		t.Errorf("found error %+v", err)
+	}
+	if len(configs) != 2 {
+		t.Fatalf("expected row with invalid time zone to be skipped but got %+v", configs)
+	}
+	if expected := time.Date(2022, 07, 22, 13, 0, 0, 0, time.UTC); !configs[0].DueTime.Equal(expected) {
+		t.Errorf("expected due time %v but got %v", expected, configs[0].DueTime)
+	}
+	if expected := time.Date(2022, 07, 22, 7, 0, 0, 0, time.UTC); !configs[1].DueTime.Equal(expected) {
+		t.Errorf("expected due time %v in default location but got %v", expected, configs[1].DueTime)
+	}
+
+	// process time is set in the default location but written in the time zone of the row
+	configs[0].ProcessTime = time.Date(2022, 07, 22, 15, 0, 0, 0, getDefaultTestLocation(t))
+	err = configStore.OverwriteConfigs(configs)
+	if err != nil {
+		t.Errorf("found error %+v", err)
+	}
+	expectedRow := "20/07/2022 13:13:13,Test 1,22/07/2022,09:00:00,01234567890,,22/07/2022 09:00:00,America/New_York,\n"
+	if !strings.Contains(buffer.String(), expectedRow) {
+		t.Errorf("expected row %s but got:\n%s", expectedRow, buffer.String())
+	}
+	if !strings.Contains(buffer.String(), ",Mars/Olympus_Mons,invalid time zone") {
+		t.Errorf("expected row with invalid time zone to be kept but got:\n%s", buffer.String())
+	}
+}
+
+func TestCSVConfigStore_TimeFormats(t *testing.T) {
+	input := strings.Join(header, ",") + "\n" +
+		"2022-07-20T13:13:13+02:00,Test 1,2022-07-22,15:15,01234567890,,\n" +
+		"44762.5,Test 2,7/22/2022,3:15 PM,01234567890,,\n"
+	openReader := func() (reader io.Reader, err error) {
+		return strings.NewReader(input), nil
+	}
+	buffer := new(bytes.Buffer)
+	openWriter := func() (writer io.Writer, err error) {
+		return buffer, nil
+	}
+	format, err := timeformat.New("2006-01-02", "15:04", []string{"1/2/2006"}, []string{"3:04 PM"})
+	if err != nil {
+		t.Fatalf("found error %+v", err)
+	}
+	configStore := NewCSVConfigStore(openReader, openWriter, *getDefaultTestLocation(t), format, CSVConfigStoreOptions{})
+
+	configs, err := configStore.GetConfigs()
+	if err != nil {
+		t.Errorf("found error %+v", err)
+	}
+	if len(configs) != 2 {
+		t.Fatalf("expected all rows to be read but got %+v", configs)
+	}
+	expectedDueTime := time.Date(2022, 07, 22, 15, 15, 0, 0, getDefaultTestLocation(t))
+	for _, config := range configs {
+		if !config.DueTime.Equal(expectedDueTime) {
+			t.Errorf("expected due time %v but got %v", expectedDueTime, config.DueTime)
+		}
+	}
+
+	err = configStore.OverwriteConfigs(configs)
+	if err != nil {
+		t.Errorf("found error %+v", err)
+	}
+	expected := strings.Join(header, ",") + "\n" +
+		"2022-07-20 13:13:13,Test 1,2022-07-22,15:15,01234567890,,\n" +
+		"2022-07-20 12:00:00,Test 2,2022-07-22,15:15,01234567890,,\n"
+	if buffer.String() != expected {
+		t.Errorf("actual:\n%s\nnot equal to expected:\n%s", buffer.String(), expected)
+	}
+}
+
+func TestCSVConfigStore_ColumnMapping(t *testing.T) {
+	input := "Notes,Uhrzeit,Datum,Nachricht,phone number\n" +
+		"call before noon,15:15:15,22/07/2022,Test 1,01234567890\n" +
+		",16:16:16,23/07/2022,Test 2,01234567890\n"
+	openReader := func() (reader io.Reader, err error) {
+		return strings.NewReader(input), nil
+	}
+	buffer := new(bytes.Buffer)
+	openWriter := func() (writer io.Writer, err error) {
+		return buffer, nil
+	}
+	columns, err := NewColumns(map[string][]string{
+		"Message Text": {"Nachricht"},
+		"Send Date":    {"Datum"},
+		"Send Time":    {"Uhrzeit"},
+	})
+	if err != nil {
+		t.Fatalf("found error %+v", err)
+	}
+	configStore := NewCSVConfigStore(openReader, openWriter, *getDefaultTestLocation(t), timeformat.Default(), CSVConfigStoreOptions{Columns: columns})
+
+	configs, err := configStore.GetConfigs()
+	if err != nil {
+		t.Fatalf("found error %+v", err)
+	}
+	if len(configs) != 2 {
+		t.Fatalf("expected 2 entries but got %+v", configs)
+	}
+	expectedDueTime := time.Date(2022, 07, 22, 15, 15, 15, 0, getDefaultTestLocation(t))
+	if configs[0].WhatsappReminderConfig.MessageText != "Test 1" || configs[0].WhatsappReminderConfig.PhoneNumber != "01234567890" ||
+		!configs[0].DueTime.Equal(expectedDueTime) || configs[0].Variables["Notes"] != "call before noon" {
+		t.Errorf("unexpected entry %+v", configs[0])
+	}
+
+	configs[1].ProcessTime = time.Date(2022, 07, 23, 16, 16, 16, 0, getDefaultTestLocation(t))
+	err = configStore.OverwriteConfigs(configs)
+	if err != nil {
+		t.Errorf("found error %+v", err)
+	}
+	expected := "Notes,Uhrzeit,Datum,Nachricht,phone number,Process Time\n" +
+		"call before noon,15:15:15,22/07/2022,Test 1,01234567890,\n" +
+		",16:16:16,23/07/2022,Test 2,01234567890,23/07/2022 16:16:16\n"
+	if buffer.String() != expected {
+		t.Errorf("actual:\n%s\nnot equal to expected:\n%s", buffer.String(), expected)
+	}
+}
+
+func TestCSVConfigStore_MissingRequiredColumn(t *testing.T) {
+	openReader := func() (reader io.Reader, err error) {
+		return strings.NewReader("Message Text,Send Date\nTest 1,22/07/2022\n"), nil
+	}
+	configStore := NewCSVConfigStore(openReader, nil, *getDefaultTestLocation(t), timeformat.Default(), CSVConfigStoreOptions{})
+
+	if _, err := configStore.GetConfigs(); err == nil {
+		t.Error("expected error for missing send time column")
+	}
+}
+
+func TestNewColumns(t *testing.T) {
+	tests := []struct {
+		name    string
+		aliases map[string][]string
+		wantErr bool
+	}{
+		{name: "no aliases"},
+		{name: "aliases", aliases: map[string][]string{"Send Date": {"Datum", "Date"}, "Lead Time": {"Vorlauf"}}},
+		{name: "unknown column", aliases: map[string][]string{"Send Day": {"Datum"}}, wantErr: true},
+		{name: "alias of another column", aliases: map[string][]string{"Send Date": {"send time"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewColumns(tt.aliases); (err != nil) != tt.wantErr {
+				t.Errorf("NewColumns() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCSVConfigStore_TimeFormatWithoutSeconds_KeepsID(t *testing.T) {
+	input := strings.Join(header, ",") + "\n" +
+		"2022-07-20 13:13:13,Test 1,2022-07-22,15:15:30,01234567890,,\n"
+	buffer := new(bytes.Buffer)
+	openWriter := func() (writer io.Writer, err error) {
+		return buffer, nil
+	}
+	format, err := timeformat.New("2006-01-02", "15:04", nil, nil)
+	if err != nil {
+		t.Fatalf("found error %+v", err)
+	}
+	configStore := NewCSVConfigStore(func() (io.Reader, error) { return strings.NewReader(input), nil }, openWriter,
+		*getDefaultTestLocation(t), format, CSVConfigStoreOptions{})
+	configs, err := configStore.GetConfigs()
+	if err != nil || len(configs) != 1 {
+		t.Fatalf("expected one entry but got %+v, %v", configs, err)
+	}
+	if err := configStore.OverwriteConfigs(configs); err != nil {
+		t.Fatalf("found error %+v", err)
+	}
+
+	written := buffer.String()
+	configStore = NewCSVConfigStore(func() (io.Reader, error) { return strings.NewReader(written), nil }, openWriter,
+		*getDefaultTestLocation(t), format, CSVConfigStoreOptions{})
+	reread, err := configStore.GetConfigs()
+	if err != nil || len(reread) != 1 {
+		t.Fatalf("expected one entry but got %+v, %v", reread, err)
+	}
+	if !reread[0].CreationTime.Equal(configs[0].CreationTime) {
+		t.Errorf("expected timestamp %v but got %v", configs[0].CreationTime, reread[0].CreationTime)
+	}
+	if reread[0].ID() != configs[0].ID() {
+		t.Errorf("expected the ID to be kept after writing:\n%s", written)
+	}
+}
+
+func TestCSVConfigStore_InvalidRows(t *testing.T) {
+	input := strings.Join(header, ",") + ",Notes,Notes,Error,Reminder Error\n" +
+		"20/07/2022 13:13:13,Test 1,22/07/2022,15:15:15,01234567890,,,,,own,old reason\n" +
+		"20/07/2022 13:13:13,Test 2,31/02/2022,15:15:15,01234567890,,,typo,second,own,\n"
+	expected := strings.Join(header, ",") + ",Notes,Notes,Error,Reminder Error\n" +
+		"20/07/2022 13:13:13,Test 1,22/07/2022,15:15:15,01234567890,,,,,own,\n" +
+		"20/07/2022 13:13:13,Test 2,31/02/2022,15:15:15,01234567890,,,typo,second,own,could not parse date '31/02/2022'\n"
+	tests := []struct {
+		name          string
+		errorTab      string
+		wantSheet     string
+		wantErrorTab  string
+		errorTabFails bool
+	}{
+		{
+			name:      "kept in sheet",
+			wantSheet: expected,
+		}, {
+			name:     "moved to error tab",
+			errorTab: "Existing,Reminder Error\nkept,row 2: other\n",
+			wantSheet: strings.Join(header, ",") + ",Notes,Notes,Error,Reminder Error\n" +
+				"20/07/2022 13:13:13,Test 1,22/07/2022,15:15:15,01234567890,,,,,own,\n",
+			wantErrorTab: "Existing,Reminder Error,Timestamp,Message Text,Send Date,Send Time,Phone Number,Mail Address,Process Time,Notes,Notes,Error\n" +
+				"kept,row 2: other\n" +
+				",row 3: could not parse date '31/02/2022',20/07/2022 13:13:13,Test 2,31/02/2022,15:15:15,01234567890,,,typo,second,own\n",
+		}, {
+			name:          "kept in sheet if error tab fails",
+			errorTabFails: true,
+			wantSheet:     expected,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			openReader := func() (reader io.Reader, err error) {
+				return strings.NewReader(input), nil
+			}
+			sheet := new(bytes.Buffer)
+			openWriter := func() (writer io.Writer, err error) {
+				return sheet, nil
+			}
+			var errorTab *ErrorTab
+			errorTabContent := new(bytes.Buffer)
+			if tt.errorTab != "" || tt.errorTabFails {
+				errorTab = NewErrorTab(func() (reader io.Reader, err error) {
+					if tt.errorTabFails {
+						return nil, errors.New("tab not found")
+					}
+					return strings.NewReader(tt.errorTab), nil
+				}, func() (writer io.Writer, err error) {
+					return errorTabContent, nil
+				})
+			}
+			configStore := NewCSVConfigStore(openReader, openWriter, *getDefaultTestLocation(t), timeformat.Default(), CSVConfigStoreOptions{ErrorTab: errorTab})
+
+			configs, err := configStore.GetConfigs()
+			if err != nil {
+				t.Fatalf("found error %+v", err)
+			}
+			if len(configs) != 1 {
+				t.Errorf("expected only the valid entry but got %+v", configs)
+			}
+			err = configStore.OverwriteConfigs(configs)
+			if err != nil {
+				t.Errorf("found error %+v", err)
+			}
+
+			if sheet.String() != tt.wantSheet {
+				t.Errorf("sheet:\n%s\nnot equal to expected:\n%s", sheet.String(), tt.wantSheet)
+			}
+			if errorTabContent.String() != tt.wantErrorTab {
+				t.Errorf("error tab:\n%s\nnot equal to expected:\n%s", errorTabContent.String(), tt.wantErrorTab)
+			}
+		})
+	}
+}
+
+func TestCSVConfigStore_MergeConfigs(t *testing.T) {
+	headerRow := strings.Join(header, ",") + "\n"
+	// a row was added and another one changed since the first read
+	changed := headerRow +
+		"20/07/2022 13:13:13,Test 1,22/07/2022,9:00,01234567890,,\n" +
+		"21/07/2022 10:00:00,Test 4,25/07/2022,9:00,01234567890,,\n" +
+		"20/07/2022 13:14:13,Test 2,21/07/2022,9:00,01234567890,,21/07/2022 09:00:00\n" +
+		"20/07/2022 13:15:13,Test 3 changed,22/07/2022,9:00,01234567890,,\n"
+	reads := []string{
+		headerRow +
+			"20/07/2022 13:13:13,Test 1,22/07/2022,9:00,01234567890,,\n" +
+			"20/07/2022 13:14:13,Test 2,21/07/2022,9:00,01234567890,,21/07/2022 09:00:00\n" +
+			"20/07/2022 13:15:13,Test 3,22/07/2022,9:00,01234567890,,\n",
+		changed,
+		// the sheet is read again right before writing
+		changed,
+	}
+	openReader := func() (reader io.Reader, err error) {
+		if len(reads) == 0 {
+			return nil, errors.New("unexpected read")
+		}
+		reader = strings.NewReader(reads[0])
+		reads = reads[1:]
+		return reader, nil
+	}
+	buffer := new(bytes.Buffer)
+	openWriter := func() (writer io.Writer, err error) {
+		return buffer, nil
+	}
+	configStore := NewCSVConfigStore(openReader, openWriter, *getDefaultTestLocation(t), timeformat.Default(), CSVConfigStoreOptions{WriteMode: WriteModeMerge})
+
+	configs, err := configStore.GetConfigs()
+	if err != nil {
+		t.Fatalf("found error %+v", err)
+	}
+	processTime := time.Date(2022, 07, 22, 9, 1, 0, 0, getDefaultTestLocation(t))
+	configs[0].ProcessTime = processTime
+	configs[2].ProcessTime = processTime
+	// the second entry expired
+	err = configStore.OverwriteConfigs([]ConfigEntry{configs[2], configs[0]})
+	if err != nil {
+		t.Errorf("found error %+v", err)
+	}
+
+	// the changed row keeps its new message text and is not sent again
+	expected := headerRow +
+		"20/07/2022 13:13:13,Test 1,22/07/2022,9:00,01234567890,,22/07/2022 09:01:00\n" +
+		"21/07/2022 10:00:00,Test 4,25/07/2022,9:00,01234567890,,\n" +
+		"20/07/2022 13:15:13,Test 3 changed,22/07/2022,9:00,01234567890,,22/07/2022 09:01:00\n"
+	if buffer.String() != expected {
+		t.Errorf("actual:\n%s\nnot equal to expected:\n%s", buffer.String(), expected)
+	}
+	if err := configStore.OverwriteConfigs(configs); err == nil {
+		t.Error("expected error when merging without reading the sheet again")
+	}
+}
+
+func TestCSVConfigStore_MergeConfigs_EditedCells(t *testing.T) {
+	headerRow := strings.Join(header, ",") + "\n"
+	// the process time was set by someone else since the first read
+	edited := headerRow +
+		"20/07/2022 13:13:13,Test 1,22/07/2022,9:00,01234567890,,21/07/2022 10:00:00\n"
+	reads := []string{
+		headerRow +
+			"20/07/2022 13:13:13,Test 1,22/07/2022,9:00,01234567890,,\n",
+		edited,
+		edited,
+	}
+	openReader := func() (reader io.Reader, err error) {
+		if len(reads) == 0 {
+			return nil, errors.New("unexpected read")
+		}
+		reader = strings.NewReader(reads[0])
+		reads = reads[1:]
+		return reader, nil
+	}
+	buffer := new(bytes.Buffer)
+	openWriter := func() (writer io.Writer, err error) {
+		return buffer, nil
+	}
+	configStore := NewCSVConfigStore(openReader, openWriter, *getDefaultTestLocation(t), timeformat.Default(), CSVConfigStoreOptions{WriteMode: WriteModeMerge})
+
+	configs, err := configStore.GetConfigs()
+	if err != nil {
+		t.Fatalf("found error %+v", err)
+	}
+	configs[0].ProcessTime = time.Date(2022, 07, 22, 9, 1, 0, 0, getDefaultTestLocation(t))
+	if err := configStore.OverwriteConfigs(configs); err != nil {
+		t.Fatalf("found error %+v", err)
+	}
+	if buffer.String() != edited {
+		t.Errorf("actual:\n%s\nnot equal to expected:\n%s", buffer.String(), edited)
+	}
+}
+
+func TestCSVConfigStore_MergeConfigs_SheetKeepsChanging(t *testing.T) {
+	headerRow := strings.Join(header, ",") + "\n"
+	reads := 0
+	openReader := func() (reader io.Reader, err error) {
+		// another row is added each time the sheet is read
+		reads++
+		data := headerRow
+		for i := 0; i < reads; i++ {
+			data += "20/07/2022 13:13:13,Test " + strconv.Itoa(i) + ",22/07/2022,9:00,01234567890,,\n"
+		}
+		return strings.NewReader(data), nil
+	}
+	openWriter := func() (writer io.Writer, err error) {
+		return nil, errors.New("unexpected write")
+	}
+	configStore := NewCSVConfigStore(openReader, openWriter, *getDefaultTestLocation(t), timeformat.Default(), CSVConfigStoreOptions{WriteMode: WriteModeMerge})
+
+	configs, err := configStore.GetConfigs()
+	if err != nil {
+		t.Fatalf("found error %+v", err)
+	}
+	err = configStore.OverwriteConfigs(configs)
+	if err == nil || !strings.Contains(err.Error(), "nothing was written") {
+		t.Errorf("expected error without writing but got %v", err)
+	}
+	if reads != 2+mergeAttempts {
+		t.Errorf("expected %d reads but got %d", 2+mergeAttempts, reads)
+	}
+}
+
+func TestCSVConfigStore_MergeConfigs_AmbiguousTimestamp(t *testing.T) {
+	headerRow := strings.Join(header, ",") + "\n"
+	// both rows of a copied reminder were edited since the first read
+	edited := headerRow +
+		"20/07/2022 13:13:13,Take pills,23/07/2022,9:00,01234567890,,\n" +
+		"20/07/2022 13:13:13,Take pills,24/07/2022,9:00,01234567890,,\n"
+	reads := []string{
+		headerRow +
+			"20/07/2022 13:13:13,Take pills,22/07/2022,9:00,01234567890,,\n" +
+			"20/07/2022 13:13:13,Take pills,23/07/2022,9:00,01234567890,,\n",
+		edited,
+	}
+	openReader := func() (reader io.Reader, err error) {
+		if len(reads) == 0 {
+			return nil, errors.New("unexpected read")
+		}
+		reader = strings.NewReader(reads[0])
+		reads = reads[1:]
+		return reader, nil
+	}
+	openWriter := func() (writer io.Writer, err error) {
+		return nil, errors.New("unexpected write")
+	}
+	configStore := NewCSVConfigStore(openReader, openWriter, *getDefaultTestLocation(t), timeformat.Default(), CSVConfigStoreOptions{WriteMode: WriteModeMerge})
+
+	configs, err := configStore.GetConfigs()
+	if err != nil {
+		t.Fatalf("found error %+v", err)
+	}
+	configs[0].ProcessTime = time.Date(2022, 07, 22, 9, 1, 0, 0, getDefaultTestLocation(t))
+	err = configStore.OverwriteConfigs(configs)
+	if err == nil || !strings.Contains(err.Error(), "cannot be told apart") {
+		t.Errorf("expected conflict without writing but got %v", err)
+	}
+}
+
+func TestCSVConfigStore_MergeConfigs_InvalidRowsMovedAfterMerge(t *testing.T) {
+	headerRow := strings.Join(header, ",") + "\n"
+	invalidRow := "20/07/2022 13:13:13,Invalid,31/02/2022,9:00,01234567890,,\n"
+	reads := 0
+	openReader := func() (reader io.Reader, err error) {
+		// another row is added each time the sheet is read
+		reads++
+		data := headerRow + invalidRow
+		for i := 0; i < reads; i++ {
+			data += "20/07/2022 13:13:13,Test " + strconv.Itoa(i) + ",22/07/2022,9:00,01234567890,,\n"
+		}
+		return strings.NewReader(data), nil
+	}
+	openWriter := func() (writer io.Writer, err error) {
+		return nil, errors.New("unexpected write")
+	}
+	errorTab := NewErrorTab(func() (reader io.Reader, err error) {
+		return strings.NewReader(""), nil
+	}, func() (writer io.Writer, err error) {
+		t.Error("expected invalid rows not to be moved if the merge fails")
+		return new(bytes.Buffer), nil
+	})
+	configStore := NewCSVConfigStore(openReader, openWriter, *getDefaultTestLocation(t), timeformat.Default(), CSVConfigStoreOptions{ErrorTab: errorTab, WriteMode: WriteModeMerge})
+
+	configs, err := configStore.GetConfigs()
+	if err != nil {
+		t.Fatalf("found error %+v", err)
+	}
+	if err := configStore.OverwriteConfigs(configs); err == nil {
+		t.Error("expected error as the sheet keeps changing")
+	}
+}
+
+func TestCSVConfigStore_MergeConfigs_FilledTimestamp(t *testing.T) {
+	input := strings.Join(header, ",") + "\n" +
+		",Test 1,22/07/2022,9:00,01234567890,,\n"
+	buffer := new(bytes.Buffer)
+	openWriter := func() (writer io.Writer, err error) {
+		return buffer, nil
+	}
+	configStore := NewCSVConfigStore(func() (io.Reader, error) { return strings.NewReader(input), nil }, openWriter,
+		*getDefaultTestLocation(t), timeformat.Default(), CSVConfigStoreOptions{WriteMode: WriteModeMerge, FillTimestamp: true})
+
+	configs, err := configStore.GetConfigs()
+	if err != nil || len(configs) != 1 {
+		t.Fatalf("expected one entry but got %+v, %v", configs, err)
+	}
+	configs[0].ProcessTime = time.Date(2022, 07, 22, 9, 1, 0, 0, getDefaultTestLocation(t))
+	if err := configStore.OverwriteConfigs(configs); err != nil {
+		t.Fatalf("found error %+v", err)
+	}
+
+	// read without filling timestamps, so only the written timestamp is used
+	written := buffer.String()
+	configStore = NewCSVConfigStore(func() (io.Reader, error) { return strings.NewReader(written), nil }, openWriter,
+		*getDefaultTestLocation(t), timeformat.Default(), CSVConfigStoreOptions{WriteMode: WriteModeMerge})
+	reread, err := configStore.GetConfigs()
+	if err != nil || len(reread) != 1 {
+		t.Fatalf("expected one entry but got %+v, %v", reread, err)
+	}
+	if !reread[0].CreationTime.Equal(configs[0].CreationTime) {
+		t.Errorf("expected the filled timestamp %v to be written but got:\n%s", configs[0].CreationTime, written)
+	}
+	if reread[0].ID() != configs[0].ID() {
+		t.Errorf("expected the ID to be kept after writing:\n%s", written)
+	}
+}
+
+func TestParseWriteMode(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    WriteMode
+		wantErr bool
+	}{
+		{value: "", want: WriteModeOverwrite},
+		{value: "overwrite", want: WriteModeOverwrite},
+		{value: "merge", want: WriteModeMerge},
+		{value: "reread", wantErr: true},
+		{value: "append", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseWriteMode(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseWriteMode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseWriteMode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package configstore
+
+import (
+	"fmt"
+)
+
+// errorColumn holds the reason why a row could not be read. The name is
+// distinctive to not claim a column of the user, aliases can rename it.
+const errorColumn = "Reminder Error"
+
+// invalidRow is a row of the sheet which could not be read
+type invalidRow struct {
+	// number is the row number in the sheet, the header is row 1
+	number int
+	cells  []string
+	reason string
+}
+
+// ErrorTab receives the rows which could not be read, so they can be fixed
+// and copied back without being processed or lost in the meantime
+type ErrorTab struct {
+	openReader openReader
+	openWriter openWriter
+}
+
+func NewErrorTab(openReader openReader, openWriter openWriter) *ErrorTab {
+	return &ErrorTab{
+		openReader: openReader,
+		openWriter: openWriter,
+	}
+}
+
+// append adds the rows with their reason below the rows already in the tab.
+// The reason is written to the error column of the sheet at errorIndex or
+// to an additional column if errorIndex is negative.
+func (tab *ErrorTab) append(headers []string, errorIndex int, rows []invalidRow) error {
+	headers = append([]string{}, headers...)
+	if errorIndex < 0 {
+		errorIndex = len(headers)
+		headers = append(headers, errorColumn)
+	}
+	data := make([][]string, 0, len(rows))
+	for _, row := range rows {
+		cells := make([]string, max(len(headers), len(row.cells)))
+		copy(cells, row.cells)
+		cells[errorIndex] = fmt.Sprintf("row %d: %s", row.number, row.reason)
+		data = append(data, cells)
+	}
+	return appendToTab(tab.openReader, tab.openWriter, headers, data)
+}
+
+// appendToTab adds the rows below the rows already in the tab. Columns are
+// matched by their header, a header which appears twice is matched to its
+// second appearance in the tab. Missing columns are added to the tab and the
+// cells of columns without header are dropped.
+func appendToTab(openReader openReader, openWriter openWriter, headers []string, rows [][]string) error {
+	reader, err := openReader()
+	if err != nil {
+		return err
+	}
+	data, err := decodeCSV(reader)
+	if err != nil {
+		return fmt.Errorf("could not read tab: %w", err)
+	}
+
+	var tabHeaders []string
+	if len(data) > 0 {
+		tabHeaders = append(tabHeaders, data[0]...)
+	} else {
+		data = append(data, nil)
+	}
+	indexes := make(map[string][]int)
+	for j, header := range tabHeaders {
+		indexes[header] = append(indexes[header], j)
+	}
+	// targets holds the column of the tab for each of the given headers
+	targets := make([]int, len(headers))
+	seen := make(map[string]int)
+	for j, header := range headers {
+		targets[j] = -1
+		if header == "" {
+			continue
+		}
+		if seen[header] == len(indexes[header]) {
+			indexes[header] = append(indexes[header], len(tabHeaders))
+			tabHeaders = append(tabHeaders, header)
+		}
+		targets[j] = indexes[header][seen[header]]
+		seen[header]++
+	}
+	data[0] = tabHeaders
+
+	for _, row := range rows {
+		cells := make([]string, len(tabHeaders))
+		for j, target := range targets {
+			if j < len(row) && target >= 0 {
+				cells[target] = row[j]
+			}
+		}
+		data = append(data, cells)
+	}
+
+	content, err := encodeCSV(data)
+	if err != nil {
+		return err
+	}
+	writer, err := openWriter()
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(content)
+	return err
+}
@@ -0,0 +1,167 @@
+package configstore
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// columns of the tab listing the imported form responses
+const (
+	importedSheetColumn = "Responses Sheet"
+	importedTimeColumn  = "Timestamp"
+	importedTextColumn  = "Message Text"
+)
+
+// FormsImportConfigStore adds new responses of a read-only Google Forms
+// response tab to the entries of the managed sheet. Imported responses are
+// listed by their timestamp in a tab of the spreadsheet, so they are imported
+// once even after their entries were removed from the sheet. Responses which
+// cannot be read are imported as soon as they were fixed. Responses which are
+// already in the sheet but not listed, e.g. because listing them failed after
+// the sheet was written, are listed instead of being imported again.
+type FormsImportConfigStore struct {
+	store              ConfigStore
+	responses          ConfigStore
+	responsesSheetName string
+	openReader         openReader
+	openWriter         openWriter
+	// pending are the responses of the last read which are not listed yet,
+	// they are listed as imported once the sheet was written
+	pending []ConfigEntry
+}
+
+func NewFormsImportConfigStore(store ConfigStore, responses ConfigStore, responsesSheetName string, openReader openReader, openWriter openWriter) *FormsImportConfigStore {
+	return &FormsImportConfigStore{
+		store:              store,
+		responses:          responses,
+		responsesSheetName: responsesSheetName,
+		openReader:         openReader,
+		openWriter:         openWriter,
+	}
+}
+
+// GetConfigs returns the entries of the sheet followed by the responses which
+// were not imported before. If the responses cannot be read, only the entries
+// of the sheet are returned.
+func (service *FormsImportConfigStore) GetConfigs() ([]ConfigEntry, error) {
+	configs, err := service.store.GetConfigs()
+	if err != nil {
+		return nil, err
+	}
+
+	service.pending = nil
+	imported, err := service.readImported()
+	if err != nil {
+		log.Printf("could not import form responses: %v", err)
+		return configs, nil
+	}
+	responses, err := service.responses.GetConfigs()
+	if err != nil {
+		log.Printf("could not import form responses: %v", err)
+		return configs, nil
+	}
+
+	inSheet := make(map[string]int)
+	for _, config := range configs {
+		inSheet[sheetKey(config)]++
+	}
+	added := 0
+	for _, response := range responses {
+		if response.CreationTime.IsZero() {
+			log.Printf("skipping form response without timestamp: %s", response.WhatsappReminderConfig.MessageText)
+			continue
+		}
+		key := importedKey(response.CreationTime)
+		if imported[key] > 0 {
+			imported[key]--
+			continue
+		}
+		service.pending = append(service.pending, response)
+		if key := sheetKey(response); inSheet[key] > 0 {
+			inSheet[key]--
+			log.Printf("form response of %s is already in the sheet, listing it as imported", importedKey(response.CreationTime))
+			continue
+		}
+		added++
+		configs = append(configs, response)
+	}
+	if added > 0 {
+		log.Printf("importing %d new form response(s)", added)
+	}
+	return configs, nil
+}
+
+// OverwriteConfigs lists the responses of the last read as imported once
+// they were written to the sheet
+func (service *FormsImportConfigStore) OverwriteConfigs(configs []ConfigEntry) error {
+	if err := service.store.OverwriteConfigs(configs); err != nil {
+		return err
+	}
+	if len(service.pending) == 0 {
+		return nil
+	}
+	rows := make([][]string, 0, len(service.pending))
+	for _, response := range service.pending {
+		rows = append(rows, []string{service.responsesSheetName, importedKey(response.CreationTime), response.WhatsappReminderConfig.MessageText})
+	}
+	headers := []string{importedSheetColumn, importedTimeColumn, importedTextColumn}
+	if err := appendToTab(service.openReader, service.openWriter, headers, rows); err != nil {
+		return fmt.Errorf("could not list %d form response(s) as imported: %w", len(rows), err)
+	}
+	service.pending = nil
+	return nil
+}
+
+// readImported counts the imported responses of the responses tab by timestamp
+func (service *FormsImportConfigStore) readImported() (map[string]int, error) {
+	reader, err := service.openReader()
+	if err != nil {
+		return nil, err
+	}
+	data, err := decodeCSV(reader)
+	if err != nil {
+		return nil, fmt.Errorf("could not read imported responses: %w", err)
+	}
+	result := make(map[string]int)
+	if len(data) == 0 {
+		return result, nil
+	}
+
+	sheetIndex, timeIndex := -1, -1
+	for j, header := range data[0] {
+		if header == importedSheetColumn && sheetIndex < 0 {
+			sheetIndex = j
+		}
+		if header == importedTimeColumn && timeIndex < 0 {
+			timeIndex = j
+		}
+	}
+	if sheetIndex < 0 || timeIndex < 0 {
+		return nil, fmt.Errorf("the tab of imported responses needs the columns '%s' and '%s'", importedSheetColumn, importedTimeColumn)
+	}
+	for i := 1; i < len(data); i++ {
+		if sheetIndex >= len(data[i]) || data[i][sheetIndex] != service.responsesSheetName {
+			continue
+		}
+		value := ""
+		if timeIndex < len(data[i]) {
+			value = data[i][timeIndex]
+		}
+		timestamp, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, fmt.Errorf("row %d of imported responses: %w", i+1, err)
+		}
+		result[importedKey(timestamp)]++
+	}
+	return result, nil
+}
+
+func importedKey(timestamp time.Time) string {
+	return timestamp.UTC().Format(time.RFC3339)
+}
+
+// sheetKey identifies an imported response among the entries of the sheet
+func sheetKey(config ConfigEntry) string {
+	return importedKey(config.CreationTime) + "\n" + config.WhatsappReminderConfig.MessageText
+}
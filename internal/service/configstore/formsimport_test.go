@@ -0,0 +1,165 @@
+package configstore
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jo-hoe/whatsapp-reminder/internal/timeformat"
+)
+
+func TestFormsImportConfigStore(t *testing.T) {
+	location := getDefaultTestLocation(t)
+	sheetEntries := []ConfigEntry{{CreationTime: time.Date(2022, 7, 1, 8, 0, 0, 0, location)}}
+	sheet := &ConfigStoreMock{ReadStore: sheetEntries}
+	responses := &memoryTab{content: "Timestamp,Message Text,Send Date,Send Time\n" +
+		"20/07/2022 13:00:00,Test 1,22/07/2022,09:00:00\n" +
+		"21/07/2022 13:00:00,Test 2,23/07/2022,09:00:00\n" +
+		"21/07/2022 14:00:00,Test 3,not a date,09:00:00\n"}
+	imported := &memoryTab{content: "Responses Sheet,Timestamp,Message Text\nOther Responses,2022-07-20T11:00:00Z,Other\n"}
+	newStore := func() *FormsImportConfigStore {
+		responseStore := NewCSVConfigStore(responses.openReader, nil, *location, timeformat.Default(), CSVConfigStoreOptions{})
+		return NewFormsImportConfigStore(sheet, responseStore, "Responses", imported.openReader, imported.openWriter)
+	}
+	messages := func(configs []ConfigEntry) []string {
+		result := make([]string, 0)
+		for _, config := range configs {
+			result = append(result, config.WhatsappReminderConfig.MessageText)
+		}
+		return result
+	}
+
+	store := newStore()
+	configs, err := store.GetConfigs()
+	if err != nil {
+		t.Fatalf("found error %+v", err)
+	}
+	if expected := []string{"", "Test 1", "Test 2"}; !reflect.DeepEqual(messages(configs), expected) {
+		t.Fatalf("expected sheet entry and valid responses %v but got %v", expected, messages(configs))
+	}
+
+	// responses are imported again as long as they were not written
+	sheet.StoreConfigResult = errors.New("quota exceeded")
+	if err := store.OverwriteConfigs(configs); err == nil {
+		t.Fatal("expected error")
+	}
+	sheet.ReadStore = sheetEntries
+	if configs, _ = store.GetConfigs(); len(configs) != 3 {
+		t.Fatalf("expected responses to be imported again but got %v", messages(configs))
+	}
+
+	// the entries were removed from the sheet, e.g. after their retention
+	sheet.StoreConfigResult = nil
+	if err := store.OverwriteConfigs(configs[:1]); err != nil {
+		t.Fatalf("found error %+v", err)
+	}
+	expectedImported := "Responses Sheet,Timestamp,Message Text\n" +
+		"Other Responses,2022-07-20T11:00:00Z,Other\n" +
+		"Responses,2022-07-20T11:00:00Z,Test 1\n" +
+		"Responses,2022-07-21T11:00:00Z,Test 2\n"
+	if imported.content != expectedImported {
+		t.Errorf("expected imported responses\n%s\nbut got\n%s", expectedImported, imported.content)
+	}
+
+	// the next run only imports new and fixed responses
+	responses.content = strings.Replace(responses.content, "not a date", "23/07/2022", 1) +
+		"22/07/2022 13:00:00,Test 4,24/07/2022,09:00:00\n"
+	sheet.ReadStore = sheetEntries[:1]
+	configs, err = newStore().GetConfigs()
+	if err != nil {
+		t.Fatalf("found error %+v", err)
+	}
+	if expected := []string{"", "Test 3", "Test 4"}; !reflect.DeepEqual(messages(configs), expected) {
+		t.Errorf("expected only new and fixed responses %v but got %v", expected, messages(configs))
+	}
+}
+
+func TestFormsImportConfigStore_ListingFails(t *testing.T) {
+	location := getDefaultTestLocation(t)
+	sheet := &ConfigStoreMock{ReadStore: []ConfigEntry{{CreationTime: time.Date(2022, 7, 1, 8, 0, 0, 0, location)}}}
+	responses := &memoryTab{content: "Timestamp,Message Text,Send Date,Send Time\n" +
+		"20/07/2022 13:00:00,Test 1,22/07/2022,09:00:00\n" +
+		"21/07/2022 13:00:00,Test 2,23/07/2022,09:00:00\n"}
+	imported := &memoryTab{content: "Responses Sheet,Timestamp,Message Text\n"}
+	failingWriter := func() (io.Writer, error) { return nil, errors.New("quota exceeded") }
+	responseStore := NewCSVConfigStore(responses.openReader, nil, *location, timeformat.Default(), CSVConfigStoreOptions{})
+	store := NewFormsImportConfigStore(sheet, responseStore, "Responses", imported.openReader, failingWriter)
+
+	configs, err := store.GetConfigs()
+	if err != nil {
+		t.Fatalf("found error %+v", err)
+	}
+	if err := store.OverwriteConfigs(configs); err == nil {
+		t.Fatal("expected error")
+	}
+
+	// the responses were written to the sheet but not listed as imported
+	store = NewFormsImportConfigStore(sheet, responseStore, "Responses", imported.openReader, imported.openWriter)
+	configs, err = store.GetConfigs()
+	if err != nil {
+		t.Fatalf("found error %+v", err)
+	}
+	if len(configs) != 3 {
+		t.Fatalf("expected responses in the sheet not to be imported again but got %+v", configs)
+	}
+	if err := store.OverwriteConfigs(configs); err != nil {
+		t.Fatalf("found error %+v", err)
+	}
+	expectedImported := "Responses Sheet,Timestamp,Message Text\n" +
+		"Responses,2022-07-20T11:00:00Z,Test 1\n" +
+		"Responses,2022-07-21T11:00:00Z,Test 2\n"
+	if imported.content != expectedImported {
+		t.Errorf("expected imported responses\n%s\nbut got\n%s", expectedImported, imported.content)
+	}
+}
+
+func TestFormsImportConfigStore_InvalidImportedTab(t *testing.T) {
+	location := getDefaultTestLocation(t)
+	sheet := &ConfigStoreMock{ReadStore: []ConfigEntry{{CreationTime: time.Date(2022, 7, 1, 8, 0, 0, 0, location)}}}
+	responses := &memoryTab{content: "Timestamp,Message Text,Send Date,Send Time\n20/07/2022 13:00:00,Test 1,22/07/2022,09:00:00\n"}
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "missing columns", content: "Timestamp\n2022-07-20T11:00:00Z\n"},
+		{name: "invalid timestamp", content: "Responses Sheet,Timestamp\nResponses,20/07/2022\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			imported := &memoryTab{content: tt.content}
+			responseStore := NewCSVConfigStore(responses.openReader, nil, *location, timeformat.Default(), CSVConfigStoreOptions{})
+			store := NewFormsImportConfigStore(sheet, responseStore, "Responses", imported.openReader, imported.openWriter)
+			configs, err := store.GetConfigs()
+			if err != nil {
+				t.Fatalf("found error %+v", err)
+			}
+			if len(configs) != 1 {
+				t.Errorf("expected no responses to be imported but got %+v", configs)
+			}
+		})
+	}
+}
+
+func TestCSVConfigStore_FillTimestamp(t *testing.T) {
+	tab := &memoryTab{content: "Timestamp,Message Text,Send Date,Send Time\n,Test 1,22/07/2022,09:00:00\n"}
+	for _, fillTimestamp := range []bool{false, true} {
+		store := NewCSVConfigStore(tab.openReader, nil, *getDefaultTestLocation(t), timeformat.Default(), CSVConfigStoreOptions{FillTimestamp: fillTimestamp})
+		before := time.Now().Truncate(time.Second)
+		configs, err := store.GetConfigs()
+		if err != nil {
+			t.Fatalf("found error %+v", err)
+		}
+		if !fillTimestamp {
+			if len(configs) != 0 {
+				t.Errorf("expected row without timestamp to be invalid but got %+v", configs)
+			}
+			continue
+		}
+		if len(configs) != 1 || configs[0].CreationTime.Before(before) || configs[0].CreationTime.After(time.Now()) {
+			t.Errorf("expected timestamp to be filled with now but got %+v", configs)
+		}
+	}
+}
@@ -0,0 +1,351 @@
+package configstore
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+)
+
+// WriteMode selects how OverwriteConfigs writes the entries to the sheet
+type WriteMode string
+
+const (
+	// WriteModeOverwrite replaces the sheet with the given entries
+	WriteModeOverwrite WriteMode = "overwrite"
+	// WriteModeMerge reads the sheet again before writing and merges the
+	// changed cells and removed rows into it, keeping rows which were added and
+	// cells which were changed by someone else since the last read. The Sheets
+	// API used here only replaces a tab as a whole, so the merged sheet is
+	// still written completely: it is read once more right before the write
+	// and nothing is written if it keeps changing, but edits in the short
+	// time between this read and the write are lost.
+	WriteModeMerge WriteMode = "merge"
+)
+
+// ParseWriteMode defaults to overwrite
+func ParseWriteMode(value string) (WriteMode, error) {
+	switch WriteMode(value) {
+	case "", WriteModeOverwrite:
+		return WriteModeOverwrite, nil
+	case WriteModeMerge:
+		return WriteModeMerge, nil
+	}
+	return "", fmt.Errorf("unknown write mode '%s', use '%s' or '%s'", value, WriteModeOverwrite, WriteModeMerge)
+}
+
+// mergeAttempts limits how often the changes are merged again if the sheet
+// changes while they are merged
+const mergeAttempts = 3
+
+// mergeConfigs applies the changes between the last read and the given entries
+// to the current content of the sheet. The sheet is read again right before it
+// is written, if it changed in the meantime the changes are merged again and
+// nothing is written after the last attempt.
+func (service *CSVConfigStore) mergeConfigs(configs []ConfigEntry) error {
+	if len(service.data) == 0 {
+		return fmt.Errorf("the sheet has to be read before changes can be merged")
+	}
+
+	current, err := service.readData()
+	if err != nil {
+		return err
+	}
+	for attempt := 1; ; attempt++ {
+		result, conflicts, err := service.mergeInto(current, configs, nil)
+		if err != nil {
+			return err
+		}
+		latest, err := service.readData()
+		if err != nil {
+			return err
+		}
+		if !slices.EqualFunc(current, latest, slices.Equal) {
+			if attempt == mergeAttempts {
+				return fmt.Errorf("the sheet was changed while merging %d times, nothing was written", mergeAttempts)
+			}
+			current = latest
+			continue
+		}
+
+		// invalid rows are moved once the merge succeeded, so they are not
+		// appended to the error tab again if the merge is retried
+		if moved := service.moveInvalidRows(); len(moved) > 0 {
+			if result, conflicts, err = service.mergeInto(current, configs, moved); err != nil {
+				return err
+			}
+		}
+		if conflicts > 0 {
+			log.Printf("%d row(s) were changed or removed since they were read and are kept unchanged", conflicts)
+		}
+		writer, err := service.openWriter()
+		if err != nil {
+			return err
+		}
+		// the last read no longer matches the sheet
+		service.data = nil
+		service.readRows = nil
+		return csv.NewWriter(writer).WriteAll(result)
+	}
+}
+
+// mergeInto returns the current data with the changes applied and the number
+// of rows whose changes could not be applied. The current data is not changed.
+func (service *CSVConfigStore) mergeInto(current [][]string, configs []ConfigEntry, moved []invalidRow) ([][]string, int, error) {
+	if len(current) == 0 {
+		return nil, 0, fmt.Errorf("the sheet was cleared since it was read")
+	}
+	rows := make([][]string, len(current))
+	for i := range current {
+		rows[i] = slices.Clone(current[i])
+	}
+	readHeaders := service.data[0]
+	headers := slices.Clone(rows[0])
+	located := locateRows(service.data, rows)
+	edited, err := service.locateEditedRows(located, rows)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	conflicts := 0
+	deleted := make(map[int]bool)
+	added := make([]ConfigEntry, 0)
+	used := make([]bool, len(service.readRows))
+	for _, config := range configs {
+		k := service.findReadRow(config, used)
+		if k < 0 {
+			added = append(added, config)
+			continue
+		}
+		used[k] = true
+		readCells := service.data[service.readRows[k].index]
+		i, ok := located[service.readRows[k].index]
+		if !ok {
+			i, ok = edited[service.readRows[k].index]
+		}
+		if !ok {
+			conflicts++
+			continue
+		}
+		changes, err := service.changedCells(service.readRows[k].entry, config)
+		if err != nil {
+			return nil, 0, err
+		}
+		// the filled timestamp is the same in both entries but has to be
+		// written, as it identifies the entry from now on
+		if service.readRows[k].filledTimestamp {
+			changes[timestampColumn] = service.writeCell(config, timestampColumn, rowFormat{format: service.format, location: &service.defaultLocation})
+		}
+		for header, value := range changes {
+			// cells edited by someone else since the last read are kept
+			if service.cell(readHeaders, readCells, header) != service.cell(headers, rows[i], header) {
+				continue
+			}
+			rows[i] = setCell(rows[i], service.headerIndex(&headers, header), value)
+		}
+		if j, ok := service.columns.locate(headers)[errorColumn]; ok && j < len(rows[i]) {
+			rows[i][j] = ""
+		}
+	}
+
+	// entries which were removed, e.g. because their retention expired
+	for k, readRow := range service.readRows {
+		if used[k] {
+			continue
+		}
+		if i, ok := located[readRow.index]; ok {
+			deleted[i] = true
+		} else {
+			conflicts++
+		}
+	}
+	for _, row := range moved {
+		if i, ok := located[row.number-1]; ok {
+			deleted[i] = true
+		}
+	}
+	for _, row := range service.invalidRows {
+		if i, ok := located[row.number-1]; ok {
+			rows[i] = setCell(rows[i], service.headerIndex(&headers, errorColumn), row.reason)
+		}
+	}
+
+	result := [][]string{headers}
+	for i := 1; i < len(rows); i++ {
+		if !deleted[i] {
+			result = append(result, rows[i])
+		}
+	}
+	for _, config := range added {
+		location, err := config.Location(&service.defaultLocation)
+		if err != nil {
+			return nil, 0, err
+		}
+		formatter := rowFormat{format: service.format, location: location}
+		row := make([]string, len(headers))
+		for j, header := range headers {
+			row[j] = service.writeCell(config, header, formatter)
+		}
+		result = append(result, row)
+	}
+	return result, conflicts, nil
+}
+
+// findReadRow returns the index of the unused read row of the entry or -1.
+// Entries are identified by the cells which are never changed by the store.
+func (service *CSVConfigStore) findReadRow(config ConfigEntry, used []bool) int {
+	for k, readRow := range service.readRows {
+		entry := readRow.entry
+		if !used[k] && entry.CreationTime.Equal(config.CreationTime) &&
+			entry.WhatsappReminderConfig.MessageText == config.WhatsappReminderConfig.MessageText &&
+			entry.WhatsappReminderConfig.PhoneNumber == config.WhatsappReminderConfig.PhoneNumber &&
+			entry.WhatsappReminderConfig.MailAddress == config.WhatsappReminderConfig.MailAddress {
+			return k
+		}
+	}
+	return -1
+}
+
+// changedCells returns the new values by header of all cells which differ
+// between the read and the given entry
+func (service *CSVConfigStore) changedCells(read ConfigEntry, config ConfigEntry) (map[string]string, error) {
+	readLocation, err := read.Location(&service.defaultLocation)
+	if err != nil {
+		return nil, err
+	}
+	location, err := config.Location(&service.defaultLocation)
+	if err != nil {
+		return nil, err
+	}
+	readFormatter := rowFormat{format: service.format, location: readLocation}
+	formatter := rowFormat{format: service.format, location: location}
+
+	headers := knownColumns()
+	for header := range config.Variables {
+		headers = append(headers, header)
+	}
+	result := make(map[string]string)
+	for _, header := range headers {
+		if value := service.writeCell(config, header, formatter); value != service.writeCell(read, header, readFormatter) {
+			result[header] = value
+		}
+	}
+	return result, nil
+}
+
+// headerIndex returns the index of the column, missing columns are added
+func (service *CSVConfigStore) headerIndex(headers *[]string, header string) int {
+	if name, ok := service.columns.name(header); ok {
+		if j, ok := service.columns.locate(*headers)[name]; ok {
+			return j
+		}
+	} else if j := slices.Index(*headers, header); j >= 0 {
+		return j
+	}
+	*headers = append(*headers, header)
+	return len(*headers) - 1
+}
+
+// locateRows returns the index of each row of the last read in the current data.
+// Rows which were changed since the last read are missing.
+func locateRows(read [][]string, current [][]string) map[int]int {
+	result := make(map[int]int)
+	used := make([]bool, len(current))
+	for i := 1; i < len(read); i++ {
+		for j := 1; j < len(current); j++ {
+			if !used[j] && slices.Equal(trimCells(read[i]), trimCells(current[j])) {
+				used[j] = true
+				result[i] = j
+				break
+			}
+		}
+	}
+	return result
+}
+
+// locateEditedRows returns the index in the current data of each row of the
+// last read which was edited since then. Rows are matched by their timestamp,
+// which is not changed by the store. Rows without timestamp cannot be matched
+// and are kept unchanged. If a timestamp appears more than once, e.g. for rows
+// copied from a recurring reminder, the rows cannot be told apart and an error
+// is returned, so no changes are applied to the wrong row.
+func (service *CSVConfigStore) locateEditedRows(located map[int]int, current [][]string) (map[int]int, error) {
+	result := make(map[int]int)
+	readIndexes := service.columns.locate(service.data[0])
+	currentIndexes := service.columns.locate(current[0])
+	if _, ok := readIndexes[timestampColumn]; !ok {
+		return result, nil
+	}
+	if _, ok := currentIndexes[timestampColumn]; !ok {
+		return result, nil
+	}
+
+	// rows by timestamp, rows which are not located yet are candidates
+	readByTimestamp := make(map[string][]int)
+	for i := 1; i < len(service.data); i++ {
+		if timestamp := strings.TrimSpace(sheetRow{cells: service.data[i], indexes: readIndexes}.get(timestampColumn)); timestamp != "" {
+			readByTimestamp[timestamp] = append(readByTimestamp[timestamp], i)
+		}
+	}
+	currentByTimestamp := make(map[string][]int)
+	for j := 1; j < len(current); j++ {
+		if timestamp := strings.TrimSpace(sheetRow{cells: current[j], indexes: currentIndexes}.get(timestampColumn)); timestamp != "" {
+			currentByTimestamp[timestamp] = append(currentByTimestamp[timestamp], j)
+		}
+	}
+	used := make(map[int]bool)
+	for _, j := range located {
+		used[j] = true
+	}
+
+	for timestamp, readRows := range readByTimestamp {
+		readCandidates := slices.DeleteFunc(slices.Clone(readRows), func(i int) bool {
+			_, ok := located[i]
+			return ok
+		})
+		currentCandidates := slices.DeleteFunc(slices.Clone(currentByTimestamp[timestamp]), func(j int) bool {
+			return used[j]
+		})
+		if len(readCandidates) == 0 || len(currentCandidates) == 0 {
+			continue
+		}
+		if len(readRows) > 1 || len(currentByTimestamp[timestamp]) > 1 {
+			return nil, fmt.Errorf("edited rows with the timestamp '%s' cannot be told apart, nothing was written", timestamp)
+		}
+		result[readCandidates[0]] = currentCandidates[0]
+	}
+	return result, nil
+}
+
+// cell returns the value of the column with the given header, empty if the
+// column or the cell is missing
+func (service *CSVConfigStore) cell(headers []string, cells []string, header string) string {
+	j := slices.Index(headers, header)
+	if name, ok := service.columns.name(header); ok {
+		if index, ok := service.columns.locate(headers)[name]; ok {
+			j = index
+		}
+	}
+	if j < 0 || j >= len(cells) {
+		return ""
+	}
+	return cells[j]
+}
+
+// trimCells drops empty cells at the end of a row
+func trimCells(cells []string) []string {
+	end := len(cells)
+	for end > 0 && cells[end-1] == "" {
+		end--
+	}
+	return cells[:end]
+}
+
+func setCell(cells []string, j int, value string) []string {
+	for len(cells) <= j {
+		cells = append(cells, "")
+	}
+	cells[j] = value
+	return cells
+}
@@ -0,0 +1,70 @@
+package contacts
+
+import (
+	"io"
+	"log"
+	"strings"
+)
+
+type Contact struct {
+	Name        string
+	Aliases     []string
+	PhoneNumber string
+}
+
+type ContactSource interface {
+	GetContacts() ([]Contact, error)
+}
+
+type openReader func() (reader io.Reader, err error)
+
+// Directory resolves contact names and aliases case-insensitively
+type Directory struct {
+	contacts map[string]Contact
+}
+
+func NewDirectory(contacts []Contact) *Directory {
+	directory := &Directory{
+		contacts: make(map[string]Contact),
+	}
+	for _, contact := range contacts {
+		for _, key := range append([]string{contact.Name}, contact.Aliases...) {
+			directory.add(key, contact)
+		}
+	}
+	return directory
+}
+
+func (directory *Directory) add(key string, contact Contact) {
+	normalizedKey := normalizeKey(key)
+	if normalizedKey == "" {
+		return
+	}
+	if existing, ok := directory.contacts[normalizedKey]; ok {
+		log.Printf("ignoring duplicate contact '%s' for %s, already used for %s", key, contact.Name, existing.Name)
+		return
+	}
+	directory.contacts[normalizedKey] = contact
+}
+
+// Resolve returns the contact with the given name or alias
+func (directory *Directory) Resolve(nameOrAlias string) (Contact, bool) {
+	if directory == nil {
+		return Contact{}, false
+	}
+	contact, ok := directory.contacts[normalizeKey(nameOrAlias)]
+	return contact, ok
+}
+
+func normalizeKey(key string) string {
+	return strings.ToLower(strings.Join(strings.Fields(key), " "))
+}
+
+type ContactSourceMock struct {
+	Contacts []Contact
+	Err      error
+}
+
+func (source *ContactSourceMock) GetContacts() ([]Contact, error) {
+	return source.Contacts, source.Err
+}
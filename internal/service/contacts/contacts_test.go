@@ -0,0 +1,79 @@
+package contacts
+
+import (
+	"io"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestCSVContactSource_GetContacts(t *testing.T) {
+	source := NewCSVContactSource(openTestFile("testdata/contacts.csv"))
+
+	actual, err := source.GetContacts()
+	if err != nil {
+		t.Errorf("found error %+v", err)
+	}
+
+	if !reflect.DeepEqual(actual, getTestContacts()) {
+		t.Errorf("GetContacts() = %+v, want %+v", actual, getTestContacts())
+	}
+}
+
+func TestVCardContactSource_GetContacts(t *testing.T) {
+	source := NewVCardContactSource(openTestFile("testdata/contacts.vcf"))
+
+	actual, err := source.GetContacts()
+	if err != nil {
+		t.Errorf("found error %+v", err)
+	}
+
+	if !reflect.DeepEqual(actual, getTestContacts()) {
+		t.Errorf("GetContacts() = %+v, want %+v", actual, getTestContacts())
+	}
+}
+
+func TestDirectory_Resolve(t *testing.T) {
+	directory := NewDirectory(getTestContacts())
+
+	tests := []struct {
+		nameOrAlias string
+		wantName    string
+		wantOk      bool
+	}{
+		{nameOrAlias: "Anna Smith", wantName: "Anna Smith", wantOk: true},
+		{nameOrAlias: " mum ", wantName: "Anna Smith", wantOk: true},
+		{nameOrAlias: "anna   smith", wantName: "Anna Smith", wantOk: true},
+		{nameOrAlias: "BOB", wantName: "Bob", wantOk: true},
+		{nameOrAlias: "+49 171 1234567", wantOk: false},
+		{nameOrAlias: "", wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.nameOrAlias, func(t *testing.T) {
+			contact, ok := directory.Resolve(tt.nameOrAlias)
+			if ok != tt.wantOk || contact.Name != tt.wantName {
+				t.Errorf("Resolve() = (%+v, %v), want (%s, %v)", contact, ok, tt.wantName, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestDirectory_Resolve_Nil(t *testing.T) {
+	var directory *Directory
+	if _, ok := directory.Resolve("Anna"); ok {
+		t.Errorf("expected nil directory to resolve nothing")
+	}
+}
+
+func openTestFile(fileName string) openReader {
+	return func() (reader io.Reader, err error) {
+		return os.Open(fileName)
+	}
+}
+
+func getTestContacts() []Contact {
+	return []Contact{
+		{Name: "Anna Smith", Aliases: []string{"Anna", "Mum"}, PhoneNumber: "+49 171 1234567"},
+		{Name: "Bob", Aliases: []string{}, PhoneNumber: "0171 7654321"},
+	}
+}
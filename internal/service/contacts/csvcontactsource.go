@@ -0,0 +1,96 @@
+package contacts
+
+import (
+	"encoding/csv"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// reads contacts from a CSV file or sheet with the columns
+// Name,Phone Number,Aliases
+// multiple aliases are separated by comma or semicolon
+
+const (
+	nameColumn        = "Name"
+	phoneNumberColumn = "Phone Number"
+	aliasesColumn     = "Aliases"
+)
+
+var aliasSeparatorRegex = regexp.MustCompile(`[,;]`)
+
+type CSVContactSource struct {
+	openReader openReader
+}
+
+func NewCSVContactSource(openReader openReader) *CSVContactSource {
+	return &CSVContactSource{
+		openReader: openReader,
+	}
+}
+
+func (source *CSVContactSource) GetContacts() ([]Contact, error) {
+	reader, err := source.openReader()
+	if err != nil {
+		return nil, err
+	}
+
+	csvReader := csv.NewReader(reader)
+	// deactivate field length validation
+	csvReader.FieldsPerRecord = -1
+	data, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return []Contact{}, nil
+	}
+
+	columns := make(map[string]int)
+	for j, column := range data[0] {
+		columns[strings.TrimSpace(column)] = j
+	}
+	nameIndex, ok := columns[nameColumn]
+	if !ok {
+		return nil, fmt.Errorf("contacts are missing the column '%s'", nameColumn)
+	}
+	phoneNumberIndex, ok := columns[phoneNumberColumn]
+	if !ok {
+		return nil, fmt.Errorf("contacts are missing the column '%s'", phoneNumberColumn)
+	}
+	aliasesIndex, hasAliases := columns[aliasesColumn]
+
+	result := make([]Contact, 0)
+	for _, row := range data[1:] {
+		contact := Contact{
+			Name:        strings.TrimSpace(getString(row, nameIndex)),
+			PhoneNumber: strings.TrimSpace(getString(row, phoneNumberIndex)),
+		}
+		if contact.Name == "" {
+			continue
+		}
+		if hasAliases {
+			contact.Aliases = splitAliases(getString(row, aliasesIndex))
+		}
+		result = append(result, contact)
+	}
+
+	return result, nil
+}
+
+func splitAliases(value string) []string {
+	result := make([]string, 0)
+	for _, alias := range aliasSeparatorRegex.Split(value, -1) {
+		if trimmed := strings.TrimSpace(alias); trimmed != "" {
+			result = append(result, trimmed)
+		}
+	}
+	return result
+}
+
+func getString(row []string, j int) string {
+	if len(row)-1 >= j {
+		return row[j]
+	}
+	return ""
+}
@@ -0,0 +1,109 @@
+package contacts
+
+import (
+	"bufio"
+	"strings"
+)
+
+// reads contacts from vCard files as described in RFC 6350
+// https://datatracker.ietf.org/doc/html/rfc6350
+// the formatted name (FN) is used as contact name, nicknames as aliases
+// and the first mobile number (or the first number if none is marked as mobile)
+
+type VCardContactSource struct {
+	openReader openReader
+}
+
+func NewVCardContactSource(openReader openReader) *VCardContactSource {
+	return &VCardContactSource{
+		openReader: openReader,
+	}
+}
+
+func (source *VCardContactSource) GetContacts() ([]Contact, error) {
+	reader, err := source.openReader()
+	if err != nil {
+		return nil, err
+	}
+
+	lines, err := readUnfoldedLines(bufio.NewScanner(reader))
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Contact, 0)
+	var current *Contact
+	mobileFound := false
+	for _, line := range lines {
+		name, params, value, ok := parseProperty(line)
+		if !ok {
+			continue
+		}
+		switch name {
+		case "BEGIN":
+			if strings.EqualFold(value, "VCARD") {
+				current = &Contact{Aliases: []string{}}
+				mobileFound = false
+			}
+		case "END":
+			if strings.EqualFold(value, "VCARD") && current != nil {
+				if current.Name != "" {
+					result = append(result, *current)
+				}
+				current = nil
+			}
+		case "FN":
+			if current != nil {
+				current.Name = unescapeValue(value)
+			}
+		case "NICKNAME":
+			if current != nil {
+				current.Aliases = append(current.Aliases, splitAliases(unescapeValue(value))...)
+			}
+		case "TEL":
+			if current == nil || mobileFound {
+				continue
+			}
+			isMobile := strings.Contains(params, "CELL") || strings.Contains(params, "MOBILE")
+			if current.PhoneNumber == "" || isMobile {
+				current.PhoneNumber = strings.TrimPrefix(value, "tel:")
+				mobileFound = isMobile
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// readUnfoldedLines joins continuation lines which start with a space or tab
+func readUnfoldedLines(scanner *bufio.Scanner) ([]string, error) {
+	lines := make([]string, 0)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// parseProperty splits a content line like "item1.TEL;TYPE=CELL:+49 171 1234567"
+// into the upper case name without group, the upper case parameters and the value
+func parseProperty(line string) (name string, params string, value string, ok bool) {
+	key, value, ok := strings.Cut(line, ":")
+	if !ok {
+		return "", "", "", false
+	}
+	name, params, _ = strings.Cut(key, ";")
+	if index := strings.LastIndex(name, "."); index >= 0 {
+		name = name[index+1:]
+	}
+	return strings.ToUpper(strings.TrimSpace(name)), strings.ToUpper(params), strings.TrimSpace(value), true
+}
+
+func unescapeValue(value string) string {
+	replacer := strings.NewReplacer(`\,`, ",", `\;`, ";", `\n`, " ", `\N`, " ", `\\`, `\`)
+	return replacer.Replace(value)
+}
@@ -0,0 +1,49 @@
+package management
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/jo-hoe/whatsapp-reminder/internal/action"
+	"github.com/jo-hoe/whatsapp-reminder/internal/service/configstore"
+)
+
+// Execute applies an action from a reminder email to its entry. Done sets the
+// acknowledged time, snooze reschedules the entry so it is sent again.
+// Actions are executed one after another as each one rewrites the store.
+func (service *ReminderManagementService) Execute(requested action.Action) error {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	configs, err := service.store.GetConfigs()
+	if err != nil {
+		return fmt.Errorf("could not read config from sheet %+v", err)
+	}
+
+	index := slices.IndexFunc(configs, func(config configstore.ConfigEntry) bool {
+		return config.MatchesID(requested.EntryID)
+	})
+	if index < 0 {
+		return action.ErrNotFound
+	}
+
+	now := time.Now().In(&service.defaultLocation).Truncate(time.Second)
+	config := &configs[index]
+	switch requested.Kind {
+	case action.Done:
+		config.AcknowledgedTime = now
+		log.Printf("reminder due at %v acknowledged", config.DueTime)
+	case action.Snooze:
+		config.DueTime = now.Add(requested.Snooze)
+		config.ProcessTime = time.Time{}
+		config.Status = ""
+		config.AcknowledgedTime = time.Time{}
+		log.Printf("reminder snoozed until %v", config.DueTime)
+	default:
+		return fmt.Errorf("unknown action '%s'", requested.Kind)
+	}
+
+	return service.store.OverwriteConfigs(configs)
+}
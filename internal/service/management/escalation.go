@@ -0,0 +1,92 @@
+package management
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/jo-hoe/whatsapp-reminder/internal/dto"
+	"github.com/jo-hoe/whatsapp-reminder/internal/service/configstore"
+)
+
+// sent reminders which are not acknowledged within the escalation delay are
+// sent again. after the max number of resends, secondary recipients are
+// notified once and the entry is marked as escalated.
+
+// Escalation configures resends of reminders which were not acknowledged.
+// The zero value disables escalation.
+type Escalation struct {
+	// After is the delay after sending until the reminder is sent again
+	After time.Duration
+	// MaxResends is the number of resends before secondary recipients are notified
+	MaxResends int
+	// AllReminders escalates reminders without a value in the escalate column
+	AllReminders bool
+}
+
+type escalationStep int
+
+const (
+	noEscalation escalationStep = iota
+	resendStep
+	notifyStep
+)
+
+// getEscalationStep returns whether a processed entry has to be sent again or escalated
+func (service *ReminderManagementService) getEscalationStep(config configstore.ConfigEntry, now time.Time) (escalationStep, error) {
+	if !service.awaitsAcknowledgement(config) {
+		return noEscalation, nil
+	}
+	enabled, err := service.isEscalated(config)
+	if err != nil || !enabled {
+		return noEscalation, err
+	}
+	if now.Sub(config.ProcessTime) < service.options.Escalation.After {
+		return noEscalation, nil
+	}
+	if config.Resends < service.options.Escalation.MaxResends {
+		return resendStep, nil
+	}
+	return notifyStep, nil
+}
+
+// awaitsAcknowledgement returns whether a sent entry may still be escalated
+func (service *ReminderManagementService) awaitsAcknowledgement(config configstore.ConfigEntry) bool {
+	return service.options.Escalation.After > 0 && !config.ProcessTime.IsZero() &&
+		config.AcknowledgedTime.IsZero() && config.Status == ""
+}
+
+// isPendingEscalation returns whether an entry has to be kept until it is acknowledged or escalated
+func (service *ReminderManagementService) isPendingEscalation(config configstore.ConfigEntry) bool {
+	enabled, err := service.isEscalated(config)
+	return err == nil && enabled && service.awaitsAcknowledgement(config)
+}
+
+func (service *ReminderManagementService) isEscalated(config configstore.ConfigEntry) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(config.Escalate)) {
+	case "":
+		return service.options.Escalation.AllReminders, nil
+	case "yes", "y", "true", "x", "1":
+		return true, nil
+	case "no", "n", "false", "0":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid escalate value '%s'", config.Escalate)
+}
+
+// escalate notifies secondary recipients and marks the entries as escalated
+func (service *ReminderManagementService) escalate(configs []configstore.ConfigEntry, escalationOrigins []int, escalationItems []dto.WhatsappReminderConfig) {
+	if len(escalationItems) == 0 {
+		return
+	}
+
+	if err := service.reminder.Escalate(escalationItems); err != nil {
+		log.Printf("could not escalate %d reminder(s): %v", len(escalationItems), err)
+		return
+	}
+	for _, index := range escalationOrigins {
+		configs[index].Status = configstore.StatusEscalated
+	}
+	log.Printf("escalated %d reminder(s) which were not acknowledged", len(escalationItems))
+}
@@ -0,0 +1,107 @@
+package management
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/jo-hoe/whatsapp-reminder/internal/service/configstore"
+)
+
+// message texts may contain placeholders which are rendered with text/template, e.g.
+// "Happy birthday {{.Name}}! Enjoy turning {{.Age}} on {{.DueDate}}."
+// available placeholders are
+// - every additional sheet column by its header, e.g. {{.Name}} for a "Name" column
+//   (spaces are removed for the placeholder name, {{index . "Column Name"}} works as well)
+// - {{.DueDate}} and {{.DueTime}} of the reminder
+// - {{.Age}} in full years at the due date if a "Birth Date" or "Birth Year" column exists
+
+const (
+	dueDateLayout   = "02/01/2006"
+	dueTimeLayout   = "15:04"
+	birthDateColumn = "Birth Date"
+	birthYearColumn = "Birth Year"
+)
+
+// renderMessageText replaces the placeholders in the message text of the entry
+func renderMessageText(config configstore.ConfigEntry, location *time.Location) (string, error) {
+	text := config.WhatsappReminderConfig.MessageText
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+
+	tmpl, err := template.New("message").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid message template: %w", err)
+	}
+	data, err := createTemplateData(config, location)
+	if err != nil {
+		return "", err
+	}
+
+	var stringBuilder strings.Builder
+	if err := tmpl.Execute(&stringBuilder, data); err != nil {
+		return "", fmt.Errorf("could not render message template: %w", err)
+	}
+	return stringBuilder.String(), nil
+}
+
+func createTemplateData(config configstore.ConfigEntry, location *time.Location) (map[string]any, error) {
+	data := make(map[string]any)
+	for column, value := range config.Variables {
+		data[column] = value
+		if placeholder := strings.ReplaceAll(column, " ", ""); placeholder != column {
+			if _, exists := config.Variables[placeholder]; !exists {
+				data[placeholder] = value
+			}
+		}
+	}
+
+	dueTime := config.DueTime.In(location)
+	data["DueDate"] = dueTime.Format(dueDateLayout)
+	data["DueTime"] = dueTime.Format(dueTimeLayout)
+
+	age, ok, err := calculateAge(config, dueTime, location)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		data["Age"] = age
+	}
+
+	return data, nil
+}
+
+// calculateAge returns the age in full years at the due time based on the birth date or year column
+func calculateAge(config configstore.ConfigEntry, dueTime time.Time, location *time.Location) (age int, ok bool, err error) {
+	if value := strings.TrimSpace(config.Variables[birthDateColumn]); value != "" {
+		birthDate, err := time.ParseInLocation(dueDateLayout, value, location)
+		if err != nil {
+			return 0, false, fmt.Errorf("invalid %s '%s': %w", birthDateColumn, value, err)
+		}
+		age = dueTime.Year() - birthDate.Year()
+		if !isBirthdayReached(dueTime, birthDate) {
+			age--
+		}
+		return age, true, nil
+	}
+
+	if value := strings.TrimSpace(config.Variables[birthYearColumn]); value != "" {
+		birthYear, err := strconv.Atoi(value)
+		if err != nil {
+			return 0, false, fmt.Errorf("invalid %s '%s': %w", birthYearColumn, value, err)
+		}
+		return dueTime.Year() - birthYear, true, nil
+	}
+
+	return 0, false, nil
+}
+
+func isBirthdayReached(dueTime time.Time, birthDate time.Time) bool {
+	if dueTime.Month() != birthDate.Month() {
+		return dueTime.Month() > birthDate.Month()
+	}
+	return dueTime.Day() >= birthDate.Day()
+}
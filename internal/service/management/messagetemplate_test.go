@@ -0,0 +1,85 @@
+package management
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jo-hoe/whatsapp-reminder/internal/dto"
+	"github.com/jo-hoe/whatsapp-reminder/internal/service/configstore"
+)
+
+func Test_renderMessageText(t *testing.T) {
+	location := getDefaultTestLocation(t)
+	dueTime := time.Date(2024, 03, 10, 9, 30, 0, 0, location)
+
+	tests := []struct {
+		name        string
+		messageText string
+		variables   map[string]string
+		want        string
+		wantErr     bool
+	}{
+		{
+			name:        "No placeholders",
+			messageText: "Hello {World}",
+			want:        "Hello {World}",
+		}, {
+			name:        "Name and due date",
+			messageText: "Happy birthday {{.Name}}! ({{.DueDate}} {{.DueTime}})",
+			variables:   map[string]string{"Name": "Anna"},
+			want:        "Happy birthday Anna! (10/03/2024 09:30)",
+		}, {
+			name:        "Age before birthday",
+			messageText: "{{.Age}}",
+			variables:   map[string]string{"Birth Date": "11/03/1990"},
+			want:        "33",
+		}, {
+			name:        "Age on birthday",
+			messageText: "{{.Age}}",
+			variables:   map[string]string{"Birth Date": "10/03/1990"},
+			want:        "34",
+		}, {
+			name:        "Age by birth year",
+			messageText: "{{.Age}}",
+			variables:   map[string]string{"Birth Year": "2000"},
+			want:        "24",
+		}, {
+			name:        "Column with spaces",
+			messageText: "{{.FavoriteCake}} / {{index . \"Favorite Cake\"}}",
+			variables:   map[string]string{"Favorite Cake": "Cheesecake"},
+			want:        "Cheesecake / Cheesecake",
+		}, {
+			name:        "Unknown placeholder",
+			messageText: "Hello {{.Nickname}}",
+			variables:   map[string]string{"Name": "Anna"},
+			wantErr:     true,
+		}, {
+			name:        "Invalid template",
+			messageText: "Hello {{.Name",
+			variables:   map[string]string{"Name": "Anna"},
+			wantErr:     true,
+		}, {
+			name:        "Invalid birth date",
+			messageText: "{{.Age}}",
+			variables:   map[string]string{"Birth Date": "1990-03-10"},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := configstore.ConfigEntry{
+				DueTime:                dueTime,
+				WhatsappReminderConfig: dto.WhatsappReminderConfig{MessageText: tt.messageText},
+				Variables:              tt.variables,
+			}
+			got, err := renderMessageText(config, location)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("renderMessageText() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("renderMessageText() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package management
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/jo-hoe/whatsapp-reminder/internal/duration"
+	"github.com/jo-hoe/whatsapp-reminder/internal/service/configstore"
+)
+
+// advance notices are sent at the due time minus each lead time, e.g. for
+// lead times "7d,1d" a week and a day before the final reminder.
+// sent notices are tracked by their lead time so none is sent twice.
+
+type notice struct {
+	leadTime time.Duration
+	// handled contains the lead times to mark as sent, including
+	// older notices which are superseded by this one
+	handled []string
+}
+
+// findDueNotice returns the most recent advance notice which is due but not
+// yet sent or nil if there is none
+func (service *ReminderManagementService) findDueNotice(config configstore.ConfigEntry, now time.Time) (*notice, error) {
+	leadTimes, err := service.getLeadTimes(config)
+	if err != nil {
+		return nil, err
+	}
+
+	var result *notice
+	for _, leadTime := range leadTimes {
+		key := duration.Format(leadTime)
+		if slices.Contains(config.NoticesSent, key) || now.Before(config.DueTime.Add(-leadTime)) {
+			continue
+		}
+		if result == nil {
+			result = &notice{leadTime: leadTime}
+		}
+		result.leadTime = min(result.leadTime, leadTime)
+		result.handled = append(result.handled, key)
+	}
+	return result, nil
+}
+
+func (service *ReminderManagementService) getLeadTimes(config configstore.ConfigEntry) ([]time.Duration, error) {
+	if config.LeadTime == "" {
+		return service.options.LeadTimes, nil
+	}
+	leadTimes, err := duration.ParseList(config.LeadTime)
+	if err != nil {
+		return nil, fmt.Errorf("invalid lead time: %w", err)
+	}
+	return leadTimes, nil
+}
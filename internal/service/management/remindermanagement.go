@@ -2,29 +2,66 @@ package management
 
 import (
 	"fmt"
+	"io"
 	"log"
 	"reflect"
 	"sort"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/jo-hoe/whatsapp-reminder/internal/businessday"
+	"github.com/jo-hoe/whatsapp-reminder/internal/calendar"
 	"github.com/jo-hoe/whatsapp-reminder/internal/dto"
+	"github.com/jo-hoe/whatsapp-reminder/internal/quiethours"
 	"github.com/jo-hoe/whatsapp-reminder/internal/service/configstore"
+	"github.com/jo-hoe/whatsapp-reminder/internal/service/contacts"
 	"github.com/jo-hoe/whatsapp-reminder/internal/service/reminder"
+	"github.com/jo-hoe/whatsapp-reminder/internal/whatsapp"
 )
 
 type ReminderManagementService struct {
+	// mutex serializes actions which read, change and write the store
+	mutex           sync.Mutex
 	store           configstore.ConfigStore
 	reminder        reminder.ReminderService
 	defaultLocation time.Location
 	retentionTime   time.Duration
+	options         Options
 }
 
-func NewReminderManagementService(store configstore.ConfigStore, reminder reminder.ReminderService, retentionTime time.Duration, defaultLocation time.Location) *ReminderManagementService {
+// Options contains optional settings of the ReminderManagementService.
+// The zero value keeps the default behavior.
+type Options struct {
+	// DefaultCountryCode is used for phone numbers given in national format
+	DefaultCountryCode string
+	// LinkStyle is used for links in exported calendars
+	LinkStyle whatsapp.LinkStyle
+	// Contacts resolves names and aliases in the phone number column
+	Contacts contacts.ContactSource
+	// LeadTimes are used for advance notices of entries without own lead times
+	LeadTimes []time.Duration
+	// QuietHours hold due reminders until the next delivery window
+	QuietHours quiethours.QuietHours
+	// BusinessDays roll due times on weekends and holidays forward to the next business day
+	BusinessDays businessday.Rules
+	// MaxLateness skips overdue reminders instead of sending them late, zero never skips
+	MaxLateness time.Duration
+	// CatchUpDigest sends a summary of skipped reminders
+	CatchUpDigest bool
+	// Escalation resends reminders which were not acknowledged
+	Escalation Escalation
+	// Archive keeps entries after the retention time instead of deleting them
+	Archive configstore.Archive
+}
+
+func NewReminderManagementService(store configstore.ConfigStore, reminder reminder.ReminderService, retentionTime time.Duration, defaultLocation time.Location, options Options) *ReminderManagementService {
 	return &ReminderManagementService{
 		store:           store,
 		reminder:        reminder,
 		retentionTime:   retentionTime,
 		defaultLocation: defaultLocation,
+		options:         options,
 	}
 }
 
@@ -37,73 +74,310 @@ func (service *ReminderManagementService) Process() error {
 	totalMessages := len(configs)
 	log.Printf("total messages seen: %d", totalMessages)
 
-	// Count already processed and not yet due messages
+	directory := service.loadContacts()
+
+	// Count advance notices, resends, already processed, not yet due, invalid and stale messages
+	advanceNotices := 0
+	resends := 0
 	alreadyProcessed := 0
 	notYetDue := 0
+	invalid := 0
 
-	// get all items which should be processed
+	// get all items which should be processed and remember their origin in configs
+	now := time.Now().In(&service.defaultLocation)
 	itemsToProcess := make([]dto.WhatsappReminderConfig, 0)
-	for _, config := range configs {
-		// skip items which are already processed or item which are not due yet
+	itemOrigins := make([]itemOrigin, 0)
+	staleItems := make([]dto.WhatsappReminderConfig, 0)
+	staleOrigins := make([]int, 0)
+	escalationItems := make([]dto.WhatsappReminderConfig, 0)
+	escalationOrigins := make([]int, 0)
+	for idx, config := range configs {
+		// skip items which are already processed unless they have to be escalated
 		if !config.ProcessTime.IsZero() {
 			alreadyProcessed++
+			step, err := service.getEscalationStep(config, now)
+			if err != nil {
+				log.Printf("not escalating reminder due at %v: %v", config.DueTime, err)
+				continue
+			}
+			if step == noEscalation {
+				continue
+			}
+			item, err := service.toReminderConfig(config, directory)
+			if err != nil {
+				log.Printf("not escalating reminder due at %v: %v", config.DueTime, err)
+				continue
+			}
+			item.EntryID = config.ID()
+			if step == notifyStep {
+				escalationItems = append(escalationItems, item)
+				escalationOrigins = append(escalationOrigins, idx)
+				continue
+			}
+			resends++
+			item.Resend = config.Resends + 1
+			itemsToProcess = append(itemsToProcess, item)
+			itemOrigins = append(itemOrigins, itemOrigin{index: idx, resend: true})
 			continue
 		}
-		if config.DueTime.After(time.Now()) {
-			notYetDue++
+		entryID := config.ID()
+		// the reason of an earlier run is set again if the entry is still invalid
+		if strings.HasPrefix(config.Status, configstore.StatusInvalid) {
+			configs[idx].Status = ""
+		}
+		config.DueTime, err = service.getDueTime(config)
+		if err != nil {
+			invalid++
+			markInvalid(&configs[idx], err)
+			continue
+		}
+		var dueNotice *notice
+		if config.DueTime.After(now) {
+			dueNotice, err = service.findDueNotice(config, now)
+			if err != nil {
+				invalid++
+				markInvalid(&configs[idx], err)
+				continue
+			}
+			if dueNotice == nil {
+				notYetDue++
+				continue
+			}
+			advanceNotices++
+		}
+		item, err := service.toReminderConfig(config, directory)
+		if err != nil {
+			invalid++
+			markInvalid(&configs[idx], err)
+			continue
+		}
+		if service.isStale(config, now) {
+			staleItems = append(staleItems, item)
+			staleOrigins = append(staleOrigins, idx)
 			continue
 		}
-		itemsToProcess = append(itemsToProcess, config.WhatsappReminderConfig)
+		item.EntryID = entryID
+		if dueNotice != nil {
+			item.LeadTime = dueNotice.leadTime
+		}
+		itemsToProcess = append(itemsToProcess, item)
+		itemOrigins = append(itemOrigins, itemOrigin{index: idx, notice: dueNotice})
 	}
 
 	messagesToProcess := len(itemsToProcess)
-	log.Printf("messages needing processing: %d (already processed: %d, not yet due: %d)",
-		messagesToProcess, alreadyProcessed, notYetDue)
-
-	if messagesToProcess > 0 {
-		// get all actually processed items
-		processedItems := service.reminder.Remind(itemsToProcess)
-
-		successfullyProcessed := len(processedItems)
-		failed := messagesToProcess - successfullyProcessed
-		log.Printf("processing complete: %d successful, %d failed", successfullyProcessed, failed)
-
-		now := time.Now().In(&service.defaultLocation)
-		for _, processedItem := range processedItems {
-			for idx := range configs {
-				if configs[idx].ProcessTime.IsZero() && reflect.DeepEqual(processedItem, configs[idx].WhatsappReminderConfig) {
-					configs[idx].ProcessTime = now
-					break
-				}
-			}
-		}
+	log.Printf("messages needing processing: %d (advance notices: %d, resends: %d, already processed: %d, not yet due: %d, invalid: %d, stale: %d, escalations: %d)",
+		messagesToProcess, advanceNotices, resends, alreadyProcessed, notYetDue, invalid, len(staleItems), len(escalationItems))
+
+	pending := messagesToProcess + len(staleItems) + len(escalationItems)
+	if pending > 0 && service.options.QuietHours.IsQuiet(now) {
+		log.Printf("quiet hours, holding %d message(s) until %v",
+			pending, service.options.QuietHours.NextDelivery(now))
 	} else {
-		log.Println("no messages to process at this time")
+		service.skipStale(configs, staleOrigins, staleItems, now)
+		service.escalate(configs, escalationOrigins, escalationItems)
+		if messagesToProcess > 0 {
+			service.remind(configs, itemsToProcess, itemOrigins, now)
+		} else {
+			log.Println("no messages to process at this time")
+		}
 	}
 
-	configs = service.filterItemByRetention(configs)
+	configs, expired := service.filterItemByRetention(configs)
+
+	// expired entries are archived before they are removed from the sheet and
+	// kept in the sheet if archiving fails, so they are never lost
+	archiveErr := service.archive(expired)
+	if archiveErr != nil {
+		configs = append(configs, expired...)
+	}
 
 	// order by due time
 	sort.Slice(configs, func(i, j int) bool {
 		return configs[i].DueTime.Before(configs[j].DueTime)
 	})
 
-	return service.store.OverwriteConfigs(configs)
+	if err := service.store.OverwriteConfigs(configs); err != nil {
+		return err
+	}
+	return archiveErr
 }
 
-func (service *ReminderManagementService) filterItemByRetention(configs []configstore.ConfigEntry) (result []configstore.ConfigEntry) {
-	result = make([]configstore.ConfigEntry, 0)
+// remind sends the reminders and marks the entries of all sent reminders as processed
+func (service *ReminderManagementService) remind(configs []configstore.ConfigEntry, itemsToProcess []dto.WhatsappReminderConfig, itemOrigins []itemOrigin, now time.Time) {
+	// get all actually processed items
+	processedItems := service.reminder.Remind(itemsToProcess)
+
+	successfullyProcessed := len(processedItems)
+	failed := len(itemsToProcess) - successfullyProcessed
+	log.Printf("processing complete: %d successful, %d failed", successfullyProcessed, failed)
+
+	matched := make([]bool, len(itemsToProcess))
+	for _, processedItem := range processedItems {
+		for i, origin := range itemOrigins {
+			if !matched[i] && reflect.DeepEqual(processedItem, itemsToProcess[i]) {
+				matched[i] = true
+				markProcessed(&configs[origin.index], origin, now)
+				break
+			}
+		}
+	}
+}
+
+// markInvalid shows in the status column why the entry is not sent, so it
+// can be fixed in the sheet instead of staying unprocessed unnoticed
+func markInvalid(config *configstore.ConfigEntry, err error) {
+	log.Printf("skipping reminder due at %v: %v", config.DueTime, err)
+	config.Status = fmt.Sprintf("%s: %v", configstore.StatusInvalid, err)
+}
+
+// itemOrigin links a reminder to its entry and the advance notice it was created for
+// or whether it was sent again because it was not acknowledged
+type itemOrigin struct {
+	index  int
+	notice *notice
+	resend bool
+}
+
+// markProcessed records the advance notice or sets the process time for the final reminder
+func markProcessed(config *configstore.ConfigEntry, origin itemOrigin, now time.Time) {
+	if origin.notice != nil {
+		config.NoticesSent = append(config.NoticesSent, origin.notice.handled...)
+		return
+	}
+	if origin.resend {
+		config.Resends++
+	}
+	config.ProcessTime = now
+}
+
+// ExportCalendar writes all pending reminders as iCalendar document
+func (service *ReminderManagementService) ExportCalendar(writer io.Writer) error {
+	configs, err := service.store.GetConfigs()
+	if err != nil {
+		return fmt.Errorf("could not read config from sheet %+v", err)
+	}
 
+	directory := service.loadContacts()
+
+	events := make([]calendar.Event, 0)
 	for _, config := range configs {
-		// check retentation only if item has been already processed
 		if !config.ProcessTime.IsZero() {
+			continue
+		}
+		config.DueTime, err = service.getDueTime(config)
+		if err != nil {
+			log.Printf("skipping reminder due at %v: %v", config.DueTime, err)
+			continue
+		}
+		item, err := service.toReminderConfig(config, directory)
+		if err != nil {
+			log.Printf("skipping reminder due at %v: %v", config.DueTime, err)
+			continue
+		}
+		events = append(events, calendar.NewReminderEvent(item, service.options.LinkStyle))
+	}
+	log.Printf("exporting %d pending reminder(s) to calendar", len(events))
+
+	_, err = io.WriteString(writer, calendar.CreateCalendar(events, time.Now()))
+	return err
+}
+
+// loadContacts returns an empty directory if no contacts are configured and
+// nil if they cannot be read, rows with names or aliases are then held
+func (service *ReminderManagementService) loadContacts() *contacts.Directory {
+	if service.options.Contacts == nil {
+		return contacts.NewDirectory(nil)
+	}
+	entries, err := service.options.Contacts.GetContacts()
+	if err != nil {
+		log.Printf("could not read contacts, holding rows without phone number: %v", err)
+		return nil
+	}
+	log.Printf("loaded %d contact(s)", len(entries))
+	return contacts.NewDirectory(entries)
+}
+
+// getDueTime returns the due time rolled forward to the next business day
+// according to the business day rule of the entry
+func (service *ReminderManagementService) getDueTime(config configstore.ConfigEntry) (time.Time, error) {
+	rule, err := service.options.BusinessDays.Get(config.BusinessDayRule)
+	if err != nil {
+		return config.DueTime, err
+	}
+	return rule.Shift(config.DueTime), nil
+}
+
+// toReminderConfig enriches the reminder with the due time of its entry,
+// renders its message text, resolves contacts and normalizes its phone number
+func (service *ReminderManagementService) toReminderConfig(config configstore.ConfigEntry, directory *contacts.Directory) (dto.WhatsappReminderConfig, error) {
+	result := config.WhatsappReminderConfig
+
+	location, err := config.Location(&service.defaultLocation)
+	if err != nil {
+		return result, err
+	}
+	result.DueTime = config.DueTime.In(location)
+
+	messageText, err := renderMessageText(config, location)
+	if err != nil {
+		return result, err
+	}
+	result.MessageText = messageText
+
+	if contact, ok := directory.Resolve(result.PhoneNumber); ok {
+		result.ContactName = contact.Name
+		result.PhoneNumber = contact.PhoneNumber
+	}
+
+	// group invites are linked as they are
+	if _, ok := whatsapp.ParseGroupInviteCode(result.PhoneNumber); ok {
+		return result, nil
+	}
+
+	phoneNumber, err := whatsapp.NormalizePhoneNumber(result.PhoneNumber, service.options.DefaultCountryCode)
+	if err != nil && directory == nil {
+		return result, fmt.Errorf("contacts could not be read to resolve '%s': %w", result.PhoneNumber, err)
+	}
+	if err != nil {
+		return result, err
+	}
+	result.PhoneNumber = phoneNumber
+
+	return result, nil
+}
+
+// filterItemByRetention removes processed entries after the retention time
+// and returns them separately
+func (service *ReminderManagementService) filterItemByRetention(configs []configstore.ConfigEntry) (result []configstore.ConfigEntry, expired []configstore.ConfigEntry) {
+	result = make([]configstore.ConfigEntry, 0)
+	expired = make([]configstore.ConfigEntry, 0)
+
+	for _, config := range configs {
+		// check retentation only if item has been already processed and is not awaiting acknowledgement
+		if !config.ProcessTime.IsZero() && !service.isPendingEscalation(config) {
 			// check if item can be filtered out based on process time comparison with retention time
 			if time.Since(config.ProcessTime) > service.retentionTime {
+				expired = append(expired, config)
 				continue
 			}
 		}
 		result = append(result, config)
 	}
 
-	return result
+	return result, expired
+}
+
+// archive keeps the expired entries before they are removed from the sheet.
+// If the sheet cannot be written afterwards, they are archived again by the
+// next run, so the archive may contain an entry twice but never loses one.
+func (service *ReminderManagementService) archive(expired []configstore.ConfigEntry) error {
+	if service.options.Archive == nil || len(expired) == 0 {
+		return nil
+	}
+	if err := service.options.Archive.Archive(expired); err != nil {
+		log.Printf("could not archive %d expired entries, keeping them in the sheet: %v", len(expired), err)
+		return fmt.Errorf("could not archive expired entries: %w", err)
+	}
+	log.Printf("archived %d expired entries", len(expired))
+	return nil
 }
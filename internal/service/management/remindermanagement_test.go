@@ -1,12 +1,22 @@
 package management
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/jo-hoe/whatsapp-reminder/internal/action"
+	"github.com/jo-hoe/whatsapp-reminder/internal/businessday"
 	"github.com/jo-hoe/whatsapp-reminder/internal/dto"
+	"github.com/jo-hoe/whatsapp-reminder/internal/quiethours"
 	"github.com/jo-hoe/whatsapp-reminder/internal/service/configstore"
+	"github.com/jo-hoe/whatsapp-reminder/internal/service/contacts"
 	"github.com/jo-hoe/whatsapp-reminder/internal/service/reminder"
 )
 
@@ -49,13 +59,18 @@ func TestReminderManagementService_Process(t *testing.T) {
 		ReadStore: []configstore.ConfigEntry{alreadyProcessedItem, notDueItem, itemToProcess},
 	}
 	mockReminder := &reminder.ReminderMock{}
-	service := NewReminderManagementService(mockStore, mockReminder, getDefaultRetention(t), *getDefaultTestLocation(t))
+	service := NewReminderManagementService(mockStore, mockReminder, getDefaultRetention(t), *getDefaultTestLocation(t), getDefaultOptions())
 
 	err := service.Process()
 	if err != nil {
 		t.Errorf("found error %+v", err)
 	}
-	if len(mockReminder.RemindResult) != 1 || mockReminder.RemindResult[0] != itemToProcess.WhatsappReminderConfig {
+	expectedItem, err := service.toReminderConfig(itemToProcess, contacts.NewDirectory(nil))
+	if err != nil {
+		t.Errorf("found error %+v", err)
+	}
+	expectedItem.EntryID = itemToProcess.ID()
+	if len(mockReminder.RemindResult) != 1 || mockReminder.RemindResult[0] != expectedItem {
 		t.Errorf("reminder was not sent as expected")
 	}
 	if len(mockStore.ReadStore) != 3 || mockStore.ReadStore[1].ProcessTime.IsZero() {
@@ -94,7 +109,7 @@ func TestReminderManagementService_Process_Order(t *testing.T) {
 	mockReminder := &reminder.ReminderMock{}
 
 	// Test
-	service := NewReminderManagementService(mockStore, mockReminder, getDefaultRetention(t), *getDefaultTestLocation(t))
+	service := NewReminderManagementService(mockStore, mockReminder, getDefaultRetention(t), *getDefaultTestLocation(t), getDefaultOptions())
 
 	// Assert
 	err := service.Process()
@@ -145,7 +160,7 @@ func TestReminderManagementService_Process_Retention(t *testing.T) {
 	}
 
 	// Test
-	service := NewReminderManagementService(mockStore, mockReminder, retention, *getDefaultTestLocation(t))
+	service := NewReminderManagementService(mockStore, mockReminder, retention, *getDefaultTestLocation(t), getDefaultOptions())
 
 	// Assert
 	err = service.Process()
@@ -161,6 +176,56 @@ func TestReminderManagementService_Process_Retention(t *testing.T) {
 	}
 }
 
+func TestReminderManagementService_Process_Archive(t *testing.T) {
+	now := time.Now()
+	expired := configstore.ConfigEntry{
+		CreationTime: now.Add(-72 * time.Hour),
+		ProcessTime:  now.Add(-25 * time.Hour),
+		DueTime:      now.Add(-25 * time.Hour),
+		WhatsappReminderConfig: dto.WhatsappReminderConfig{
+			PhoneNumber: "0123456789",
+			MessageText: "hallo",
+		},
+	}
+	retention, err := time.ParseDuration("24h")
+	if err != nil {
+		t.Errorf("found error %+v", err)
+	}
+
+	tests := []struct {
+		name          string
+		storeResult   error
+		archiveResult error
+		wantErr       bool
+		wantArchived  []configstore.ConfigEntry
+		wantKept      int
+	}{
+		{name: "archived", wantArchived: []configstore.ConfigEntry{expired}},
+		// the entry is archived again by the next run
+		{name: "write fails", storeResult: errors.New("quota exceeded"), wantErr: true, wantArchived: []configstore.ConfigEntry{expired}},
+		{name: "archive fails", archiveResult: errors.New("quota exceeded"), wantErr: true, wantKept: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := &configstore.ConfigStoreMock{ReadStore: []configstore.ConfigEntry{expired}, StoreConfigResult: tt.storeResult}
+			archive := &configstore.ArchiveMock{ArchiveResult: tt.archiveResult}
+			options := getDefaultOptions()
+			options.Archive = archive
+			service := NewReminderManagementService(mockStore, &reminder.ReminderMock{}, retention, *getDefaultTestLocation(t), options)
+
+			if err := service.Process(); (err != nil) != tt.wantErr {
+				t.Errorf("Process() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(mockStore.ReadStore) != tt.wantKept {
+				t.Errorf("expected %d entries to be kept but got %+v", tt.wantKept, mockStore.ReadStore)
+			}
+			if !reflect.DeepEqual(archive.Archived, tt.wantArchived) {
+				t.Errorf("expected archived entries %+v but got %+v", tt.wantArchived, archive.Archived)
+			}
+		})
+	}
+}
+
 func TestReminderManagementService_Process_DuplicateRows(t *testing.T) {
 	now := time.Now()
 
@@ -185,7 +250,7 @@ func TestReminderManagementService_Process_DuplicateRows(t *testing.T) {
 		ReadStore: []configstore.ConfigEntry{duplicateItem1, duplicateItem2},
 	}
 	mockReminder := &reminder.ReminderMock{}
-	service := NewReminderManagementService(mockStore, mockReminder, getDefaultRetention(t), *getDefaultTestLocation(t))
+	service := NewReminderManagementService(mockStore, mockReminder, getDefaultRetention(t), *getDefaultTestLocation(t), getDefaultOptions())
 
 	err := service.Process()
 	if err != nil {
@@ -203,6 +268,669 @@ func TestReminderManagementService_Process_DuplicateRows(t *testing.T) {
 	}
 }
 
+func TestReminderManagementService_Process_InvalidPhoneNumber(t *testing.T) {
+	now := time.Now()
+
+	invalidItem := configstore.ConfigEntry{
+		CreationTime: now.Add(-72 * time.Hour),
+		DueTime:      now.Add(-1 * time.Hour),
+		WhatsappReminderConfig: dto.WhatsappReminderConfig{
+			PhoneNumber: "0123 CALL ME",
+			MessageText: "invalid",
+		},
+	}
+	validItem := configstore.ConfigEntry{
+		CreationTime: now.Add(-72 * time.Hour),
+		DueTime:      now.Add(-1 * time.Hour),
+		WhatsappReminderConfig: dto.WhatsappReminderConfig{
+			PhoneNumber: "+49 (0)123-456789",
+			MessageText: "valid",
+		},
+	}
+	mockStore := &configstore.ConfigStoreMock{
+		ReadStore: []configstore.ConfigEntry{invalidItem, validItem},
+	}
+	mockReminder := &reminder.ReminderMock{}
+	service := NewReminderManagementService(mockStore, mockReminder, getDefaultRetention(t), *getDefaultTestLocation(t), getDefaultOptions())
+
+	err := service.Process()
+	if err != nil {
+		t.Errorf("found error %+v", err)
+	}
+	if len(mockReminder.RemindResult) != 1 || mockReminder.RemindResult[0].PhoneNumber != "49123456789" {
+		t.Errorf("expected only the valid reminder with normalized number but got %+v", mockReminder.RemindResult)
+	}
+	if len(mockStore.ReadStore) != 2 || !mockStore.ReadStore[0].ProcessTime.IsZero() || mockStore.ReadStore[1].ProcessTime.IsZero() {
+		t.Errorf("expected invalid item to be kept unprocessed but got %+v", mockStore.ReadStore)
+	}
+	if !strings.HasPrefix(mockStore.ReadStore[0].Status, configstore.StatusInvalid+": ") || mockStore.ReadStore[1].Status != "" {
+		t.Errorf("expected only the invalid item to be marked with its reason but got %+v", mockStore.ReadStore)
+	}
+}
+
+func TestReminderManagementService_Process_NationalNumberWithoutCountryCode(t *testing.T) {
+	now := time.Now()
+
+	item := configstore.ConfigEntry{
+		CreationTime: now.Add(-72 * time.Hour),
+		DueTime:      now.Add(-1 * time.Hour),
+		WhatsappReminderConfig: dto.WhatsappReminderConfig{
+			PhoneNumber: "0171 123456",
+			MessageText: "national",
+		},
+	}
+	mockStore := &configstore.ConfigStoreMock{
+		ReadStore: []configstore.ConfigEntry{item},
+	}
+	mockReminder := &reminder.ReminderMock{}
+	options := getDefaultOptions()
+	options.DefaultCountryCode = ""
+	service := NewReminderManagementService(mockStore, mockReminder, getDefaultRetention(t), *getDefaultTestLocation(t), options)
+
+	err := service.Process()
+	if err != nil {
+		t.Errorf("found error %+v", err)
+	}
+	if len(mockReminder.RemindResult) != 0 {
+		t.Errorf("expected no reminder but got %+v", mockReminder.RemindResult)
+	}
+	status := mockStore.ReadStore[0].Status
+	if !strings.HasPrefix(status, configstore.StatusInvalid+": ") || !strings.Contains(status, "country code") {
+		t.Errorf("expected status to name the missing country code but got '%s'", status)
+	}
+
+	// the reason is cleared once the entry can be sent
+	service.options.DefaultCountryCode = "49"
+	err = service.Process()
+	if err != nil {
+		t.Errorf("found error %+v", err)
+	}
+	if len(mockReminder.RemindResult) != 1 || mockStore.ReadStore[0].Status != "" || mockStore.ReadStore[0].ProcessTime.IsZero() {
+		t.Errorf("expected entry to be sent with empty status but got %+v", mockStore.ReadStore)
+	}
+}
+
+func TestReminderManagementService_Process_GroupInvite(t *testing.T) {
+	now := time.Now()
+
+	groupItem := configstore.ConfigEntry{
+		CreationTime: now.Add(-72 * time.Hour),
+		DueTime:      now.Add(-1 * time.Hour),
+		WhatsappReminderConfig: dto.WhatsappReminderConfig{
+			PhoneNumber: "https://chat.whatsapp.com/AbCdEfGhIjKlMnOpQrStUv",
+			MessageText: "group",
+		},
+	}
+	mockStore := &configstore.ConfigStoreMock{
+		ReadStore: []configstore.ConfigEntry{groupItem},
+	}
+	mockReminder := &reminder.ReminderMock{}
+	service := NewReminderManagementService(mockStore, mockReminder, getDefaultRetention(t), *getDefaultTestLocation(t), getDefaultOptions())
+
+	err := service.Process()
+	if err != nil {
+		t.Errorf("found error %+v", err)
+	}
+	if len(mockReminder.RemindResult) != 1 || mockReminder.RemindResult[0].PhoneNumber != groupItem.WhatsappReminderConfig.PhoneNumber {
+		t.Errorf("expected group invite to be sent unchanged but got %+v", mockReminder.RemindResult)
+	}
+}
+
+func TestReminderManagementService_Process_MessageTemplate(t *testing.T) {
+	now := time.Now()
+
+	templateItem := configstore.ConfigEntry{
+		CreationTime: now.Add(-72 * time.Hour),
+		DueTime:      now.Add(-1 * time.Hour),
+		WhatsappReminderConfig: dto.WhatsappReminderConfig{
+			PhoneNumber: "0123456789",
+			MessageText: "Happy birthday {{.Name}}!",
+		},
+		Variables: map[string]string{"Name": "Anna"},
+	}
+	brokenItem := configstore.ConfigEntry{
+		CreationTime: now.Add(-72 * time.Hour),
+		DueTime:      now.Add(-1 * time.Hour),
+		WhatsappReminderConfig: dto.WhatsappReminderConfig{
+			PhoneNumber: "0123456789",
+			MessageText: "Happy birthday {{.Nickname}}!",
+		},
+	}
+	mockStore := &configstore.ConfigStoreMock{
+		ReadStore: []configstore.ConfigEntry{templateItem, brokenItem},
+	}
+	mockReminder := &reminder.ReminderMock{}
+	service := NewReminderManagementService(mockStore, mockReminder, getDefaultRetention(t), *getDefaultTestLocation(t), getDefaultOptions())
+
+	err := service.Process()
+	if err != nil {
+		t.Errorf("found error %+v", err)
+	}
+	if len(mockReminder.RemindResult) != 1 || mockReminder.RemindResult[0].MessageText != "Happy birthday Anna!" {
+		t.Errorf("expected only the rendered reminder but got %+v", mockReminder.RemindResult)
+	}
+	if mockStore.ReadStore[0].WhatsappReminderConfig.MessageText != templateItem.WhatsappReminderConfig.MessageText {
+		t.Errorf("expected the template to be kept in the store but got %s", mockStore.ReadStore[0].WhatsappReminderConfig.MessageText)
+	}
+}
+
+func TestReminderManagementService_Process_Contacts(t *testing.T) {
+	now := time.Now()
+
+	contactItem := configstore.ConfigEntry{
+		CreationTime: now.Add(-72 * time.Hour),
+		DueTime:      now.Add(-1 * time.Hour),
+		WhatsappReminderConfig: dto.WhatsappReminderConfig{
+			PhoneNumber: "mum",
+			MessageText: "hallo",
+		},
+	}
+	mockStore := &configstore.ConfigStoreMock{
+		ReadStore: []configstore.ConfigEntry{contactItem},
+	}
+	mockReminder := &reminder.ReminderMock{}
+	options := getDefaultOptions()
+	options.Contacts = &contacts.ContactSourceMock{
+		Contacts: []contacts.Contact{{Name: "Anna", Aliases: []string{"Mum"}, PhoneNumber: "0171 1234567"}},
+	}
+	service := NewReminderManagementService(mockStore, mockReminder, getDefaultRetention(t), *getDefaultTestLocation(t), options)
+
+	err := service.Process()
+	if err != nil {
+		t.Errorf("found error %+v", err)
+	}
+	if len(mockReminder.RemindResult) != 1 {
+		t.Fatalf("expected one reminder but got %+v", mockReminder.RemindResult)
+	}
+	if mockReminder.RemindResult[0].PhoneNumber != "491711234567" || mockReminder.RemindResult[0].ContactName != "Anna" {
+		t.Errorf("expected resolved contact but got %+v", mockReminder.RemindResult[0])
+	}
+	if mockStore.ReadStore[0].WhatsappReminderConfig.PhoneNumber != "mum" || mockStore.ReadStore[0].ProcessTime.IsZero() {
+		t.Errorf("expected alias to be kept in the store and entry processed but got %+v", mockStore.ReadStore[0])
+	}
+}
+
+func TestReminderManagementService_Process_ContactsUnavailable(t *testing.T) {
+	now := time.Now()
+
+	item := configstore.ConfigEntry{
+		CreationTime: now.Add(-72 * time.Hour),
+		DueTime:      now.Add(-1 * time.Hour),
+		WhatsappReminderConfig: dto.WhatsappReminderConfig{
+			PhoneNumber: "0171 1234567",
+			MessageText: "hallo",
+		},
+	}
+	aliasItem := item
+	aliasItem.WhatsappReminderConfig.PhoneNumber = "mum"
+	mockStore := &configstore.ConfigStoreMock{
+		ReadStore: []configstore.ConfigEntry{item, aliasItem},
+	}
+	mockReminder := &reminder.ReminderMock{}
+	options := getDefaultOptions()
+	options.Contacts = &contacts.ContactSourceMock{Err: errors.New("sheet not found")}
+	service := NewReminderManagementService(mockStore, mockReminder, getDefaultRetention(t), *getDefaultTestLocation(t), options)
+
+	err := service.Process()
+	if err != nil {
+		t.Errorf("found error %+v", err)
+	}
+	if len(mockReminder.RemindResult) != 1 || mockReminder.RemindResult[0].PhoneNumber != "491711234567" {
+		t.Errorf("expected reminder with the number of the sheet but got %+v", mockReminder.RemindResult)
+	}
+	for _, entry := range mockStore.ReadStore {
+		isAlias := entry.WhatsappReminderConfig.PhoneNumber == "mum"
+		if entry.ProcessTime.IsZero() != isAlias {
+			t.Errorf("expected only the entry with the phone number to be processed but got %+v", entry)
+		}
+	}
+}
+
+func TestReminderManagementService_Process_AdvanceNotices(t *testing.T) {
+	now := time.Now()
+
+	// both the 7d and 1d notices are due, only the most recent one is sent
+	upcomingItem := configstore.ConfigEntry{
+		CreationTime: now.Add(-72 * time.Hour),
+		DueTime:      now.Add(12 * time.Hour),
+		WhatsappReminderConfig: dto.WhatsappReminderConfig{
+			PhoneNumber: "0123456789",
+			MessageText: "buy a gift",
+		},
+	}
+	// the row lead time overrides the default lead times
+	alreadyNotifiedItem := configstore.ConfigEntry{
+		CreationTime: now.Add(-72 * time.Hour),
+		DueTime:      now.Add(12 * time.Hour),
+		LeadTime:     "1d",
+		NoticesSent:  []string{"1d"},
+		WhatsappReminderConfig: dto.WhatsappReminderConfig{
+			PhoneNumber: "0123456789",
+			MessageText: "already notified",
+		},
+	}
+	farItem := configstore.ConfigEntry{
+		CreationTime: now.Add(-72 * time.Hour),
+		DueTime:      now.Add(10 * 24 * time.Hour),
+		WhatsappReminderConfig: dto.WhatsappReminderConfig{
+			PhoneNumber: "0123456789",
+			MessageText: "far away",
+		},
+	}
+	mockStore := &configstore.ConfigStoreMock{
+		ReadStore: []configstore.ConfigEntry{upcomingItem, alreadyNotifiedItem, farItem},
+	}
+	mockReminder := &reminder.ReminderMock{}
+	options := getDefaultOptions()
+	options.LeadTimes = []time.Duration{7 * 24 * time.Hour, 24 * time.Hour}
+	service := NewReminderManagementService(mockStore, mockReminder, getDefaultRetention(t), *getDefaultTestLocation(t), options)
+
+	err := service.Process()
+	if err != nil {
+		t.Errorf("found error %+v", err)
+	}
+	if len(mockReminder.RemindResult) != 1 || mockReminder.RemindResult[0].LeadTime != 24*time.Hour {
+		t.Errorf("expected one advance notice with lead time 1d but got %+v", mockReminder.RemindResult)
+	}
+	expectedNotices := []string{"7d", "1d"}
+	if !reflect.DeepEqual(mockStore.ReadStore[0].NoticesSent, expectedNotices) || !mockStore.ReadStore[0].ProcessTime.IsZero() {
+		t.Errorf("expected notices %v without process time but got %+v", expectedNotices, mockStore.ReadStore[0])
+	}
+
+	// the next run must not send the notice again
+	mockReminder.RemindResult = nil
+	err = service.Process()
+	if err != nil {
+		t.Errorf("found error %+v", err)
+	}
+	if len(mockReminder.RemindResult) != 0 {
+		t.Errorf("expected no reminders on second run but got %+v", mockReminder.RemindResult)
+	}
+}
+
+func TestReminderManagementService_Process_QuietHours(t *testing.T) {
+	now := time.Now()
+
+	itemToProcess := configstore.ConfigEntry{
+		CreationTime: now.Add(-72 * time.Hour),
+		DueTime:      now.Add(-1 * time.Hour),
+		WhatsappReminderConfig: dto.WhatsappReminderConfig{
+			PhoneNumber: "0123456789",
+			MessageText: "hallo",
+		},
+	}
+	mockStore := &configstore.ConfigStoreMock{
+		ReadStore: []configstore.ConfigEntry{itemToProcess},
+	}
+	mockReminder := &reminder.ReminderMock{}
+	// quiet around the clock except for one minute which has already passed
+	start := now.Add(-2 * time.Minute).In(getDefaultTestLocation(t)).Format("15:04")
+	end := now.Add(-3 * time.Minute).In(getDefaultTestLocation(t)).Format("15:04")
+	quietHours, err := quiethours.Parse(start, end, false, getDefaultTestLocation(t))
+	if err != nil {
+		t.Errorf("found error %+v", err)
+	}
+	options := getDefaultOptions()
+	options.QuietHours = quietHours
+	service := NewReminderManagementService(mockStore, mockReminder, getDefaultRetention(t), *getDefaultTestLocation(t), options)
+
+	err = service.Process()
+	if err != nil {
+		t.Errorf("found error %+v", err)
+	}
+	if len(mockReminder.RemindResult) != 0 {
+		t.Errorf("expected reminders to be held but got %+v", mockReminder.RemindResult)
+	}
+	if len(mockStore.ReadStore) != 1 || !mockStore.ReadStore[0].ProcessTime.IsZero() {
+		t.Errorf("expected held reminder to stay unprocessed but got %+v", mockStore.ReadStore)
+	}
+}
+
+func TestReminderManagementService_Process_BusinessDays(t *testing.T) {
+	now := time.Now()
+	dueTime := now.Add(-1 * time.Hour)
+
+	holidayItem := configstore.ConfigEntry{
+		CreationTime:    now.Add(-72 * time.Hour),
+		DueTime:         dueTime,
+		BusinessDayRule: "holidays",
+		WhatsappReminderConfig: dto.WhatsappReminderConfig{
+			PhoneNumber: "0123456789",
+			MessageText: "holiday",
+		},
+	}
+	noRuleItem := holidayItem
+	noRuleItem.BusinessDayRule = "none"
+	noRuleItem.WhatsappReminderConfig.MessageText = "no rule"
+	invalidItem := holidayItem
+	invalidItem.BusinessDayRule = "unknown"
+	invalidItem.WhatsappReminderConfig.MessageText = "invalid"
+
+	mockStore := &configstore.ConfigStoreMock{
+		ReadStore: []configstore.ConfigEntry{holidayItem, noRuleItem, invalidItem},
+	}
+	mockReminder := &reminder.ReminderMock{}
+	businessDays, err := businessday.NewRules("", &holidaysMock{holiday: dueTime})
+	if err != nil {
+		t.Errorf("found error %+v", err)
+	}
+	options := getDefaultOptions()
+	options.BusinessDays = businessDays
+	service := NewReminderManagementService(mockStore, mockReminder, getDefaultRetention(t), *getDefaultTestLocation(t), options)
+
+	err = service.Process()
+	if err != nil {
+		t.Errorf("found error %+v", err)
+	}
+	if len(mockReminder.RemindResult) != 1 || mockReminder.RemindResult[0].MessageText != "no rule" {
+		t.Errorf("expected only the reminder without rule to be sent but got %+v", mockReminder.RemindResult)
+	}
+	for _, config := range mockStore.ReadStore {
+		if !config.DueTime.Equal(dueTime) {
+			t.Errorf("expected due time %v to be stored unchanged but got %v", dueTime, config.DueTime)
+		}
+	}
+}
+
+type holidaysMock struct {
+	holiday time.Time
+}
+
+func (mock *holidaysMock) IsHoliday(date time.Time) bool {
+	return date.Format(time.DateOnly) == mock.holiday.Format(time.DateOnly)
+}
+
+func TestReminderManagementService_Process_MaxLateness(t *testing.T) {
+	now := time.Now()
+
+	staleItem := configstore.ConfigEntry{
+		CreationTime: now.Add(-72 * time.Hour),
+		DueTime:      now.Add(-48 * time.Hour),
+		WhatsappReminderConfig: dto.WhatsappReminderConfig{
+			PhoneNumber: "0123456789",
+			MessageText: "stale",
+		},
+	}
+	lateItem := staleItem
+	lateItem.DueTime = now.Add(-1 * time.Hour)
+	lateItem.WhatsappReminderConfig.MessageText = "late"
+
+	mockStore := &configstore.ConfigStoreMock{
+		ReadStore: []configstore.ConfigEntry{staleItem, lateItem},
+	}
+	mockReminder := &reminder.ReminderMock{}
+	options := getDefaultOptions()
+	options.MaxLateness = 24 * time.Hour
+	options.CatchUpDigest = true
+	service := NewReminderManagementService(mockStore, mockReminder, getDefaultRetention(t), *getDefaultTestLocation(t), options)
+
+	err := service.Process()
+	if err != nil {
+		t.Errorf("found error %+v", err)
+	}
+	if len(mockReminder.RemindResult) != 1 || mockReminder.RemindResult[0].MessageText != "late" {
+		t.Errorf("expected only the late reminder to be sent but got %+v", mockReminder.RemindResult)
+	}
+	if len(mockReminder.DigestResult) != 1 || mockReminder.DigestResult[0].MessageText != "stale" {
+		t.Errorf("expected the stale reminder in the digest but got %+v", mockReminder.DigestResult)
+	}
+	if len(mockStore.ReadStore) != 2 || mockStore.ReadStore[0].Status != configstore.StatusSkipped || mockStore.ReadStore[0].ProcessTime.IsZero() {
+		t.Errorf("expected stale reminder to be marked as skipped but got %+v", mockStore.ReadStore)
+	}
+	if mockStore.ReadStore[1].Status != "" || mockStore.ReadStore[1].ProcessTime.IsZero() {
+		t.Errorf("expected late reminder to be marked as sent but got %+v", mockStore.ReadStore[1])
+	}
+}
+
+func TestReminderManagementService_Process_Escalation(t *testing.T) {
+	now := time.Now()
+
+	sentItem := configstore.ConfigEntry{
+		CreationTime: now.Add(-72 * time.Hour),
+		DueTime:      now.Add(-72 * time.Hour),
+		ProcessTime:  now.Add(-12 * time.Hour),
+		Escalate:     "yes",
+		WhatsappReminderConfig: dto.WhatsappReminderConfig{
+			PhoneNumber: "0123456789",
+			MessageText: "resend",
+		},
+	}
+	resentItem := sentItem
+	resentItem.Resends = 1
+	resentItem.ProcessTime = now.Add(-5 * time.Hour)
+	resentItem.WhatsappReminderConfig.MessageText = "escalate"
+	acknowledgedItem := sentItem
+	acknowledgedItem.AcknowledgedTime = now.Add(-11 * time.Hour)
+	acknowledgedItem.WhatsappReminderConfig.MessageText = "acknowledged"
+	optOutItem := sentItem
+	optOutItem.Escalate = "no"
+	optOutItem.WhatsappReminderConfig.MessageText = "opt out"
+
+	mockStore := &configstore.ConfigStoreMock{
+		ReadStore: []configstore.ConfigEntry{sentItem, resentItem, acknowledgedItem, optOutItem},
+	}
+	mockReminder := &reminder.ReminderMock{}
+	options := getDefaultOptions()
+	options.Escalation = Escalation{After: 4 * time.Hour, MaxResends: 1}
+	service := NewReminderManagementService(mockStore, mockReminder, 6*time.Hour, *getDefaultTestLocation(t), options)
+
+	err := service.Process()
+	if err != nil {
+		t.Errorf("found error %+v", err)
+	}
+	if len(mockReminder.RemindResult) != 1 || mockReminder.RemindResult[0].MessageText != "resend" || mockReminder.RemindResult[0].Resend != 1 {
+		t.Errorf("expected the unacknowledged reminder to be sent again but got %+v", mockReminder.RemindResult)
+	}
+	if len(mockReminder.EscalateResult) != 1 || mockReminder.EscalateResult[0].MessageText != "escalate" {
+		t.Errorf("expected the reminder after all resends to be escalated but got %+v", mockReminder.EscalateResult)
+	}
+	// acknowledged and opted out entries are removed by retention
+	if len(mockStore.ReadStore) != 2 {
+		t.Fatalf("expected two entries but got %+v", mockStore.ReadStore)
+	}
+	for _, config := range mockStore.ReadStore {
+		if config.WhatsappReminderConfig.MessageText == "resend" && (config.Resends != 1 || now.Sub(config.ProcessTime) > time.Minute) {
+			t.Errorf("expected resend to be recorded but got %+v", config)
+		}
+		if config.WhatsappReminderConfig.MessageText == "escalate" && config.Status != configstore.StatusEscalated {
+			t.Errorf("expected entry to be marked as escalated but got %+v", config)
+		}
+	}
+}
+
+func TestReminderManagementService_Execute(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+
+	processedItem := configstore.ConfigEntry{
+		CreationTime: now.Add(-72 * time.Hour),
+		DueTime:      now.Add(-2 * time.Hour),
+		ProcessTime:  now.Add(-1 * time.Hour),
+		WhatsappReminderConfig: dto.WhatsappReminderConfig{
+			PhoneNumber: "0123456789",
+			MessageText: "hallo",
+		},
+	}
+
+	tests := []struct {
+		name    string
+		action  action.Action
+		wantErr error
+		check   func(t *testing.T, config configstore.ConfigEntry)
+	}{
+		{
+			name:   "done",
+			action: action.Action{Kind: action.Done, EntryID: processedItem.ID()},
+			check: func(t *testing.T, config configstore.ConfigEntry) {
+				if config.AcknowledgedTime.IsZero() {
+					t.Errorf("expected acknowledged time to be set but got %+v", config)
+				}
+			},
+		}, {
+			name:   "snooze",
+			action: action.Action{Kind: action.Snooze, EntryID: processedItem.ID(), Snooze: time.Hour},
+			check: func(t *testing.T, config configstore.ConfigEntry) {
+				if !config.ProcessTime.IsZero() || config.DueTime.Before(now.Add(time.Hour)) {
+					t.Errorf("expected entry to be rescheduled but got %+v", config)
+				}
+			},
+		}, {
+			name:   "done with ID of an earlier version",
+			action: action.Action{Kind: action.Done, EntryID: legacyID(processedItem)},
+			check: func(t *testing.T, config configstore.ConfigEntry) {
+				if config.AcknowledgedTime.IsZero() {
+					t.Errorf("expected acknowledged time to be set but got %+v", config)
+				}
+			},
+		}, {
+			name:    "not found",
+			action:  action.Action{Kind: action.Done, EntryID: "unknown"},
+			wantErr: action.ErrNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := &configstore.ConfigStoreMock{
+				ReadStore: []configstore.ConfigEntry{processedItem},
+			}
+			service := NewReminderManagementService(mockStore, &reminder.ReminderMock{}, getDefaultRetention(t), *getDefaultTestLocation(t), getDefaultOptions())
+
+			err := service.Execute(tt.action)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Execute() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.check != nil {
+				tt.check(t, mockStore.ReadStore[0])
+			}
+		})
+	}
+}
+
+// legacyID returns the ID of links sent by earlier versions, including the due time
+func legacyID(config configstore.ConfigEntry) string {
+	hash := sha256.Sum256([]byte(strings.Join([]string{
+		strconv.FormatInt(config.CreationTime.Unix(), 10),
+		strconv.FormatInt(config.DueTime.Unix(), 10),
+		config.WhatsappReminderConfig.PhoneNumber,
+		config.WhatsappReminderConfig.MessageText,
+	}, "\x00")))
+	return hex.EncodeToString(hash[:12])
+}
+
+func TestReminderManagementService_Execute_LinksKeptAfterSnooze(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+
+	processedItem := configstore.ConfigEntry{
+		CreationTime: now.Add(-72 * time.Hour),
+		DueTime:      now.Add(-2 * time.Hour),
+		ProcessTime:  now.Add(-1 * time.Hour),
+		WhatsappReminderConfig: dto.WhatsappReminderConfig{
+			PhoneNumber: "0123456789",
+			MessageText: "hallo",
+		},
+	}
+	mockStore := &configstore.ConfigStoreMock{
+		ReadStore: []configstore.ConfigEntry{processedItem},
+	}
+	service := NewReminderManagementService(mockStore, &reminder.ReminderMock{}, getDefaultRetention(t), *getDefaultTestLocation(t), getDefaultOptions())
+
+	// the links of one email share the ID of the entry
+	entryID := processedItem.ID()
+	if err := service.Execute(action.Action{Kind: action.Snooze, EntryID: entryID, Snooze: time.Hour}); err != nil {
+		t.Fatalf("found error %+v", err)
+	}
+	if err := service.Execute(action.Action{Kind: action.Done, EntryID: entryID}); err != nil {
+		t.Fatalf("expected done link to work after snooze but got %v", err)
+	}
+	if mockStore.ReadStore[0].AcknowledgedTime.IsZero() {
+		t.Errorf("expected acknowledged time to be set but got %+v", mockStore.ReadStore[0])
+	}
+}
+
+func TestReminderManagementService_Process_MaxLatenessAfterQuietHours(t *testing.T) {
+	now := time.Now()
+
+	// held by the quiet hours which ended an hour ago
+	heldItem := configstore.ConfigEntry{
+		CreationTime: now.Add(-72 * time.Hour),
+		DueTime:      now.Add(-9 * time.Hour),
+		WhatsappReminderConfig: dto.WhatsappReminderConfig{
+			PhoneNumber: "0123456789",
+			MessageText: "held",
+		},
+	}
+	// held by the quiet hours of the day before
+	staleItem := heldItem
+	staleItem.DueTime = now.Add(-30 * time.Hour)
+	staleItem.WhatsappReminderConfig.MessageText = "stale"
+
+	mockStore := &configstore.ConfigStoreMock{
+		ReadStore: []configstore.ConfigEntry{staleItem, heldItem},
+	}
+	mockReminder := &reminder.ReminderMock{}
+	start := now.Add(-10 * time.Hour).In(getDefaultTestLocation(t)).Format("15:04")
+	end := now.Add(-1 * time.Hour).In(getDefaultTestLocation(t)).Format("15:04")
+	quietHours, err := quiethours.Parse(start, end, false, getDefaultTestLocation(t))
+	if err != nil {
+		t.Errorf("found error %+v", err)
+	}
+	options := getDefaultOptions()
+	options.QuietHours = quietHours
+	options.MaxLateness = 2 * time.Hour
+	service := NewReminderManagementService(mockStore, mockReminder, getDefaultRetention(t), *getDefaultTestLocation(t), options)
+
+	err = service.Process()
+	if err != nil {
+		t.Errorf("found error %+v", err)
+	}
+	if len(mockReminder.RemindResult) != 1 || mockReminder.RemindResult[0].MessageText != "held" {
+		t.Errorf("expected the reminder held by quiet hours to be sent but got %+v", mockReminder.RemindResult)
+	}
+	if len(mockStore.ReadStore) != 2 || mockStore.ReadStore[0].Status != configstore.StatusSkipped || mockStore.ReadStore[1].Status != "" {
+		t.Errorf("expected only the reminder of the day before to be skipped but got %+v", mockStore.ReadStore)
+	}
+}
+
+func TestReminderManagementService_ExportCalendar(t *testing.T) {
+	now := time.Now()
+
+	processedItem := configstore.ConfigEntry{
+		CreationTime: now.Add(-72 * time.Hour),
+		ProcessTime:  now.Add(-1 * time.Hour),
+		DueTime:      now.Add(-2 * time.Hour),
+		WhatsappReminderConfig: dto.WhatsappReminderConfig{
+			PhoneNumber: "0123456789",
+			MessageText: "processed",
+		},
+	}
+	pendingItem := configstore.ConfigEntry{
+		CreationTime: now.Add(-72 * time.Hour),
+		DueTime:      now.Add(2 * time.Hour),
+		WhatsappReminderConfig: dto.WhatsappReminderConfig{
+			PhoneNumber: "0123456789",
+			MessageText: "pending",
+		},
+	}
+	mockStore := &configstore.ConfigStoreMock{
+		ReadStore: []configstore.ConfigEntry{processedItem, pendingItem},
+	}
+	service := NewReminderManagementService(mockStore, &reminder.ReminderMock{}, getDefaultRetention(t), *getDefaultTestLocation(t), getDefaultOptions())
+
+	var buffer bytes.Buffer
+	err := service.ExportCalendar(&buffer)
+	if err != nil {
+		t.Errorf("found error %+v", err)
+	}
+
+	actual := buffer.String()
+	if strings.Count(actual, "BEGIN:VEVENT") != 1 {
+		t.Errorf("expected exactly one event but found %d", strings.Count(actual, "BEGIN:VEVENT"))
+	}
+	if !strings.Contains(actual, "SUMMARY:pending") || strings.Contains(actual, "processed") {
+		t.Errorf("expected only pending reminder in calendar:\n%s", actual)
+	}
+}
+
 func getDefaultTestLocation(t *testing.T) *time.Location {
 	location, err := time.LoadLocation("Europe/Berlin")
 
@@ -213,6 +941,12 @@ func getDefaultTestLocation(t *testing.T) *time.Location {
 	return location
 }
 
+func getDefaultOptions() Options {
+	return Options{
+		DefaultCountryCode: "49",
+	}
+}
+
 func getDefaultRetention(t *testing.T) time.Duration {
 	duration, err := time.ParseDuration("72h")
 
@@ -0,0 +1,41 @@
+package management
+
+import (
+	"log"
+	"time"
+
+	"github.com/jo-hoe/whatsapp-reminder/internal/dto"
+	"github.com/jo-hoe/whatsapp-reminder/internal/service/configstore"
+)
+
+// reminders which are overdue by more than the max lateness, e.g. after a
+// longer downtime, are not sent anymore but marked as skipped. optionally a
+// digest summarizes the skipped reminders.
+
+// isStale measures the lateness from the first delivery window after the due
+// time, so reminders held by quiet hours are not skipped when they end
+func (service *ReminderManagementService) isStale(config configstore.ConfigEntry, now time.Time) bool {
+	deliverableSince := service.options.QuietHours.NextDelivery(config.DueTime)
+	return service.options.MaxLateness > 0 && now.Sub(deliverableSince) > service.options.MaxLateness
+}
+
+// skipStale marks the entries of stale reminders as skipped and sends the digest if enabled
+func (service *ReminderManagementService) skipStale(configs []configstore.ConfigEntry, staleOrigins []int, staleItems []dto.WhatsappReminderConfig, now time.Time) {
+	if len(staleItems) == 0 {
+		return
+	}
+
+	for i, index := range staleOrigins {
+		log.Printf("skipping stale reminder due at %v, %v overdue", staleItems[i].DueTime, now.Sub(staleItems[i].DueTime).Round(time.Minute))
+		configs[index].ProcessTime = now
+		configs[index].Status = configstore.StatusSkipped
+	}
+	log.Printf("skipped %d stale reminder(s) overdue by more than %v", len(staleItems), service.options.MaxLateness)
+
+	if !service.options.CatchUpDigest {
+		return
+	}
+	if err := service.reminder.Digest(staleItems); err != nil {
+		log.Printf("could not send catch-up digest: %v", err)
+	}
+}
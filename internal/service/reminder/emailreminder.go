@@ -7,9 +7,14 @@ import (
 	"html"
 	"log"
 	"strings"
+	"time"
 
+	"github.com/jo-hoe/whatsapp-reminder/internal/action"
+	"github.com/jo-hoe/whatsapp-reminder/internal/calendar"
+	"github.com/jo-hoe/whatsapp-reminder/internal/config"
 	"github.com/jo-hoe/whatsapp-reminder/internal/dto"
 	"github.com/jo-hoe/whatsapp-reminder/internal/whatsapp"
+	qrcode "github.com/skip2/go-qrcode"
 )
 
 //go:embed template_start.html
@@ -21,83 +26,286 @@ var mailItem string
 //go:embed template_end.html
 var mailEnd string
 
+//go:embed template_qrcode.html
+var mailQRCode string
+
+//go:embed template_digest_start.html
+var mailDigestStart string
+
+//go:embed template_actions.html
+var mailActions string
+
+//go:embed template_escalation_start.html
+var mailEscalationStart string
+
+const (
+	mailSubject           = "WhatsApp Reminder"
+	mailDigestSubject     = "WhatsApp Reminder: skipped reminders"
+	mailEscalationSubject = "WhatsApp Reminder: not acknowledged"
+	qrCodeSize            = 256
+	upcomingLayout        = "02/01/2006 15:04"
+)
+
 type EmailReminderService struct {
-	mailClient MailClientInterface
-	from       string
-	to         []string
-	ctx        context.Context
+	mailClient  MailClientInterface
+	cfg         config.EmailConfig
+	ctx         context.Context
+	actionLinks *action.LinkCreator
 }
 
+// NewEmailReminderService creates the service, actionLinks is optional
+// and adds links to mark reminders as done or to snooze them
 func NewEmailReminderService(
 	mailClient MailClientInterface,
-	from string,
-	to []string,
-	ctx context.Context) *EmailReminderService {
+	cfg config.EmailConfig,
+	ctx context.Context,
+	actionLinks *action.LinkCreator) *EmailReminderService {
 	return &EmailReminderService{
-		mailClient: mailClient,
-		from:       from,
-		to:         to,
-		ctx:        ctx,
+		mailClient:  mailClient,
+		cfg:         cfg,
+		ctx:         ctx,
+		actionLinks: actionLinks,
 	}
 }
 
 func (service *EmailReminderService) Remind(messageConfigs []dto.WhatsappReminderConfig) (result []dto.WhatsappReminderConfig) {
-	log.Printf("sending emails to %d recipient(s) with %d total reminder(s)", len(service.to), len(messageConfigs))
-
-	htmlContent := service.buildHtmlContent(messageConfigs)
+	htmlContent, inlineImages := service.buildHtmlContent(messageConfigs)
+	requests := service.buildMailRequests(service.recipients(), mailSubject, htmlContent, inlineImages, service.buildAttachments(messageConfigs))
+	log.Printf("sending %d email(s) with %d total reminder(s)", len(requests), len(messageConfigs))
 
 	result = make([]dto.WhatsappReminderConfig, 0)
-	successCount := 0
+	if service.send(requests, len(messageConfigs)) > 0 {
+		result = append(result, messageConfigs...)
+	}
+	return result
+}
+
+// Digest sends a summary of reminders which were skipped instead of sent
+func (service *EmailReminderService) Digest(messageConfigs []dto.WhatsappReminderConfig) error {
+	htmlContent, inlineImages := service.renderHtmlContent(mailDigestStart, messageConfigs, skippedLabel)
+	requests := service.buildMailRequests(service.recipients(), mailDigestSubject, htmlContent, inlineImages, nil)
+	log.Printf("sending %d email(s) with a digest of %d skipped reminder(s)", len(requests), len(messageConfigs))
+
+	if service.send(requests, len(messageConfigs)) == 0 {
+		return fmt.Errorf("could not send digest of %d skipped reminder(s)", len(messageConfigs))
+	}
+	return nil
+}
+
+// Escalate notifies the escalation recipients about reminders which were not acknowledged
+func (service *EmailReminderService) Escalate(messageConfigs []dto.WhatsappReminderConfig) error {
+	if len(service.cfg.EscalationTo) == 0 {
+		return fmt.Errorf("no escalation recipients configured, not notifying about %d reminder(s)", len(messageConfigs))
+	}
+
+	htmlContent, inlineImages := service.renderHtmlContent(mailEscalationStart, messageConfigs, escalatedLabel)
+	requests := service.buildMailRequests(recipients{to: service.cfg.EscalationTo}, mailEscalationSubject, htmlContent, inlineImages, nil)
+	log.Printf("sending %d email(s) escalating %d reminder(s)", len(requests), len(messageConfigs))
+
+	if service.send(requests, len(messageConfigs)) == 0 {
+		return fmt.Errorf("could not escalate %d reminder(s)", len(messageConfigs))
+	}
+	return nil
+}
+
+// send sends all requests and returns the number of successfully sent reminders
+func (service *EmailReminderService) send(requests []MailRequest, reminderCount int) (successCount int) {
 	failureCount := 0
 
-	for _, recipient := range service.to {
-		req := MailRequest{
-			To:          recipient,
-			Subject:     "WhatsApp Reminder",
-			HtmlContent: htmlContent,
-			From:        service.from,
-		}
-		log.Printf("sending %d reminder(s) to %s", len(messageConfigs), recipient)
+	for _, req := range requests {
+		recipients := strings.Join(req.Recipients(), ", ")
+		log.Printf("sending %d reminder(s) to %s", reminderCount, recipients)
 
 		err := service.mailClient.SendMail(service.ctx, req)
 		if err != nil {
-			failureCount += len(messageConfigs)
-			log.Printf("failed to send %d reminder(s) to %s: %v", len(messageConfigs), recipient, err)
+			failureCount += reminderCount
+			log.Printf("failed to send %d reminder(s) to %s: %v", reminderCount, recipients, err)
 		} else {
-			successCount += len(messageConfigs)
-			log.Printf("successfully sent %d reminder(s) to %s", len(messageConfigs), recipient)
+			successCount += reminderCount
+			log.Printf("successfully sent %d reminder(s) to %s", reminderCount, recipients)
 		}
 	}
 
-	if successCount > 0 {
-		result = append(result, messageConfigs...)
+	log.Printf("email sending summary: %d successful, %d failed out of %d total reminder(s)",
+		successCount, failureCount, reminderCount)
+
+	return successCount
+}
+
+type recipients struct {
+	to  []string
+	cc  []string
+	bcc []string
+}
+
+func (service *EmailReminderService) recipients() recipients {
+	return recipients{to: service.cfg.To, cc: service.cfg.Cc, bcc: service.cfg.Bcc}
+}
+
+// buildMailRequests either creates a single mail addressed to all recipients
+// or a separate mail for every to, cc and bcc address
+func (service *EmailReminderService) buildMailRequests(recipients recipients, subject string, htmlContent string, inlineImages []Attachment, attachments []Attachment) []MailRequest {
+	if service.cfg.CombineRecipients {
+		return []MailRequest{{
+			To:           recipients.to,
+			Cc:           recipients.cc,
+			Bcc:          recipients.bcc,
+			ReplyTo:      service.cfg.ReplyTo,
+			Subject:      subject,
+			HtmlContent:  htmlContent,
+			From:         service.cfg.From,
+			InlineImages: inlineImages,
+			Attachments:  attachments,
+		}}
 	}
 
-	log.Printf("email sending summary: %d successful, %d failed out of %d total reminder(s)",
-		successCount, failureCount, len(messageConfigs))
+	requests := make([]MailRequest, 0, len(recipients.to)+len(recipients.cc)+len(recipients.bcc))
+	appendRequest := func(recipient string, hidden bool) {
+		req := MailRequest{
+			ReplyTo:      service.cfg.ReplyTo,
+			Subject:      subject,
+			HtmlContent:  htmlContent,
+			From:         service.cfg.From,
+			InlineImages: inlineImages,
+			Attachments:  attachments,
+		}
+		if hidden {
+			req.Bcc = []string{recipient}
+		} else {
+			req.To = []string{recipient}
+		}
+		requests = append(requests, req)
+	}
+	for _, recipient := range recipients.to {
+		appendRequest(recipient, false)
+	}
+	for _, recipient := range recipients.cc {
+		appendRequest(recipient, false)
+	}
+	for _, recipient := range recipients.bcc {
+		appendRequest(recipient, true)
+	}
+	return requests
+}
 
-	return result
+// buildHtmlContent renders the mail body. If QR codes are enabled, the
+// returned images have to be attached inline to the mail.
+func (service *EmailReminderService) buildHtmlContent(messageConfigs []dto.WhatsappReminderConfig) (string, []Attachment) {
+	return service.renderHtmlContent(mailStart, messageConfigs, reminderLabel)
 }
 
-func (service *EmailReminderService) buildHtmlContent(messageConfigs []dto.WhatsappReminderConfig) string {
+// itemLabel returns a prefix for the message text of a reminder
+type itemLabel func(messageConfig dto.WhatsappReminderConfig) string
+
+func reminderLabel(messageConfig dto.WhatsappReminderConfig) string {
+	if messageConfig.LeadTime > 0 {
+		return fmt.Sprintf("[upcoming %s] ", messageConfig.DueTime.Format(upcomingLayout))
+	}
+	if messageConfig.Resend > 0 {
+		return fmt.Sprintf("[not acknowledged, resend %d] ", messageConfig.Resend)
+	}
+	return ""
+}
+
+func skippedLabel(messageConfig dto.WhatsappReminderConfig) string {
+	return fmt.Sprintf("[skipped, due %s] ", messageConfig.DueTime.Format(upcomingLayout))
+}
+
+func escalatedLabel(messageConfig dto.WhatsappReminderConfig) string {
+	return fmt.Sprintf("[not acknowledged, due %s] ", messageConfig.DueTime.Format(upcomingLayout))
+}
+
+func (service *EmailReminderService) renderHtmlContent(start string, messageConfigs []dto.WhatsappReminderConfig, label itemLabel) (string, []Attachment) {
 	var stringBuilder strings.Builder
-	stringBuilder.WriteString(mailStart)
+	stringBuilder.WriteString(start)
 
-	for _, messageConfig := range messageConfigs {
-		whatsappLink := whatsapp.CreateWhatsappLink(messageConfig.PhoneNumber, messageConfig.MessageText)
+	inlineImages := make([]Attachment, 0)
+	for i, messageConfig := range messageConfigs {
+		whatsappLink := whatsapp.CreateLink(service.linkStyle(), messageConfig.PhoneNumber, messageConfig.MessageText)
 
 		htmlEscapedText := html.EscapeString(messageConfig.MessageText)
 		if len(htmlEscapedText) > 61 {
 			htmlEscapedText = htmlEscapedText[:61] + "..."
 		}
+		htmlEscapedText = label(messageConfig) + htmlEscapedText
 
 		number := messageConfig.PhoneNumber
 		if len(number) == 0 {
 			number = "no number provided"
 		}
+		if len(messageConfig.ContactName) > 0 {
+			number = fmt.Sprintf("%s (%s)", messageConfig.ContactName, formatPhoneNumber(messageConfig.PhoneNumber))
+		}
+		number = html.EscapeString(number)
+
+		qrCodeHtml := ""
+		if service.cfg.QRCodes {
+			image, err := createQRCode(whatsappLink, i)
+			if err != nil {
+				log.Printf("could not create QR code for '%s': %v", whatsappLink, err)
+			} else {
+				inlineImages = append(inlineImages, image)
+				qrCodeHtml = fmt.Sprintf(mailQRCode, image.ContentID, qrCodeSize/2, qrCodeSize/2)
+			}
+		}
 
-		fmt.Fprintf(&stringBuilder, mailItem, whatsappLink, htmlEscapedText, number)
+		fmt.Fprintf(&stringBuilder, mailItem, whatsappLink, htmlEscapedText, number, qrCodeHtml, service.buildActionsHtml(messageConfig))
 	}
 	stringBuilder.WriteString(mailEnd)
-	return stringBuilder.String()
+	return stringBuilder.String(), inlineImages
+}
+
+// buildActionsHtml returns the action links for final reminders if enabled
+func (service *EmailReminderService) buildActionsHtml(messageConfig dto.WhatsappReminderConfig) string {
+	if service.actionLinks == nil || messageConfig.EntryID == "" || messageConfig.LeadTime > 0 {
+		return ""
+	}
+
+	links := make([]string, 0)
+	for _, link := range service.actionLinks.CreateLinks(messageConfig.EntryID, time.Now()) {
+		links = append(links, fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(link.URL), html.EscapeString(link.Label)))
+	}
+	return fmt.Sprintf(mailActions, strings.Join(links, " | "))
+}
+
+// buildAttachments creates an iCalendar file with the reminders if enabled
+func (service *EmailReminderService) buildAttachments(messageConfigs []dto.WhatsappReminderConfig) []Attachment {
+	if !service.cfg.ICSAttachment {
+		return nil
+	}
+
+	events := make([]calendar.Event, 0, len(messageConfigs))
+	for _, messageConfig := range messageConfigs {
+		events = append(events, calendar.NewReminderEvent(messageConfig, service.linkStyle()))
+	}
+	return []Attachment{{
+		Filename:    "reminders.ics",
+		ContentType: "text/calendar; charset=\"UTF-8\"; method=PUBLISH",
+		Data:        []byte(calendar.CreateCalendar(events, time.Now())),
+	}}
+}
+
+// formatPhoneNumber adds the plus sign to normalized numbers for display
+func formatPhoneNumber(phoneNumber string) string {
+	if len(phoneNumber) > 0 && strings.Trim(phoneNumber, "0123456789") == "" {
+		return "+" + phoneNumber
+	}
+	return phoneNumber
+}
+
+func (service *EmailReminderService) linkStyle() whatsapp.LinkStyle {
+	return whatsapp.LinkStyle(service.cfg.LinkStyle)
+}
+
+func createQRCode(content string, index int) (Attachment, error) {
+	png, err := qrcode.Encode(content, qrcode.Medium, qrCodeSize)
+	if err != nil {
+		return Attachment{}, err
+	}
+	return Attachment{
+		Filename:    fmt.Sprintf("qrcode-%d.png", index),
+		ContentType: "image/png",
+		ContentID:   fmt.Sprintf("qrcode-%d@whatsapp-reminder", index),
+		Data:        png,
+	}, nil
 }
@@ -2,9 +2,13 @@ package reminder
 
 import (
 	"context"
+	"errors"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/jo-hoe/whatsapp-reminder/internal/action"
+	"github.com/jo-hoe/whatsapp-reminder/internal/config"
 	"github.com/jo-hoe/whatsapp-reminder/internal/dto"
 )
 
@@ -26,8 +30,8 @@ func Test_Remind(t *testing.T) {
 	mock := &MockMailClient{
 		SentMails: make([]MailRequest, 0),
 	}
-	to := []string{"recipient@test.com"}
-	service := NewEmailReminderService(mock, "sender@test.com", to, context.Background())
+	cfg := config.EmailConfig{From: "sender@test.com", To: []string{"recipient@test.com"}}
+	service := NewEmailReminderService(mock, cfg, context.Background(), nil)
 	testSet := []dto.WhatsappReminderConfig{
 		{MessageText: "Text 1", MailAddress: "a@mail.com"},
 		{PhoneNumber: "0123", MessageText: "Text 2", MailAddress: "a@mail.com"},
@@ -39,7 +43,7 @@ func Test_Remind(t *testing.T) {
 	if len(mock.SentMails) != 1 {
 		t.Errorf("Expected 1 mail (one per config recipient) but found %d", len(mock.SentMails))
 	}
-	if mock.SentMails[0].To != "recipient@test.com" {
+	if len(mock.SentMails[0].To) != 1 || mock.SentMails[0].To[0] != "recipient@test.com" {
 		t.Errorf("Expected recipient@test.com but got %s", mock.SentMails[0].To)
 	}
 	if len(actual) != len(testSet) {
@@ -47,9 +51,118 @@ func Test_Remind(t *testing.T) {
 	}
 }
 
+func Test_Remind_SeparateRecipients(t *testing.T) {
+	mock := &MockMailClient{
+		SentMails: make([]MailRequest, 0),
+	}
+	cfg := config.EmailConfig{
+		From:    "sender@test.com",
+		To:      []string{"a@test.com", "b@test.com"},
+		Cc:      []string{"c@test.com"},
+		Bcc:     []string{"d@test.com"},
+		ReplyTo: "reply@test.com",
+	}
+	service := NewEmailReminderService(mock, cfg, context.Background(), nil)
+
+	service.Remind([]dto.WhatsappReminderConfig{{MessageText: "Text 1"}})
+
+	if len(mock.SentMails) != 4 {
+		t.Fatalf("Expected 4 mails (one per recipient) but found %d", len(mock.SentMails))
+	}
+	for _, mail := range mock.SentMails {
+		if len(mail.Recipients()) != 1 {
+			t.Errorf("Expected exactly one recipient per mail but got %v", mail.Recipients())
+		}
+		if mail.ReplyTo != "reply@test.com" {
+			t.Errorf("Expected reply-to reply@test.com but got %s", mail.ReplyTo)
+		}
+	}
+	if len(mock.SentMails[3].To) != 0 || mock.SentMails[3].Bcc[0] != "d@test.com" {
+		t.Errorf("Expected bcc recipient to be hidden but got %+v", mock.SentMails[3])
+	}
+}
+
+func Test_Remind_CombinedRecipients(t *testing.T) {
+	mock := &MockMailClient{
+		SentMails: make([]MailRequest, 0),
+	}
+	cfg := config.EmailConfig{
+		From:              "sender@test.com",
+		To:                []string{"a@test.com", "b@test.com"},
+		Cc:                []string{"c@test.com"},
+		Bcc:               []string{"d@test.com"},
+		CombineRecipients: true,
+	}
+	service := NewEmailReminderService(mock, cfg, context.Background(), nil)
+
+	actual := service.Remind([]dto.WhatsappReminderConfig{{MessageText: "Text 1"}})
+
+	if len(mock.SentMails) != 1 {
+		t.Fatalf("Expected 1 combined mail but found %d", len(mock.SentMails))
+	}
+	if len(mock.SentMails[0].Recipients()) != 4 {
+		t.Errorf("Expected 4 envelope recipients but got %v", mock.SentMails[0].Recipients())
+	}
+	if len(actual) != 1 {
+		t.Errorf("Expected 1 returned config but got %d", len(actual))
+	}
+}
+
+func Test_buildMessage(t *testing.T) {
+	message, err := buildMessage(MailRequest{
+		From:        "sender@test.com",
+		To:          []string{"a@test.com", "b@test.com"},
+		Cc:          []string{"c@test.com"},
+		Bcc:         []string{"d@test.com"},
+		ReplyTo:     "reply@test.com",
+		Subject:     "subject",
+		HtmlContent: "<p>content</p>",
+	})
+
+	if err != nil {
+		t.Errorf("found error %+v", err)
+	}
+	if !strings.Contains(message, "To: a@test.com, b@test.com\r\n") {
+		t.Errorf("Expected combined To header in %q", message)
+	}
+	if !strings.Contains(message, "Cc: c@test.com\r\n") {
+		t.Errorf("Expected Cc header in %q", message)
+	}
+	if !strings.Contains(message, "Reply-To: reply@test.com\r\n") {
+		t.Errorf("Expected Reply-To header in %q", message)
+	}
+	if strings.Contains(message, "d@test.com") {
+		t.Errorf("Expected bcc recipient not to be part of the headers in %q", message)
+	}
+}
+
+func Test_Remind_ICSAttachment(t *testing.T) {
+	mock := &MockMailClient{
+		SentMails: make([]MailRequest, 0),
+	}
+	cfg := config.EmailConfig{From: "sender@test.com", To: []string{"r@test.com"}, ICSAttachment: true}
+	service := NewEmailReminderService(mock, cfg, context.Background(), nil)
+
+	service.Remind([]dto.WhatsappReminderConfig{
+		{MessageText: "Text 1", DueTime: time.Date(2022, 07, 22, 15, 15, 15, 0, time.UTC)},
+		{MessageText: "Text 2", DueTime: time.Date(2022, 07, 23, 15, 15, 15, 0, time.UTC)},
+	})
+
+	if len(mock.SentMails) != 1 || len(mock.SentMails[0].Attachments) != 1 {
+		t.Fatalf("Expected 1 mail with 1 attachment but found %+v", mock.SentMails)
+	}
+	content := string(mock.SentMails[0].Attachments[0].Data)
+	if strings.Count(content, "BEGIN:VEVENT") != 2 {
+		t.Errorf("Expected 2 events but found %d in:\n%s", strings.Count(content, "BEGIN:VEVENT"), content)
+	}
+	if !strings.Contains(content, "DTSTART:20220722T151515Z") {
+		t.Errorf("Expected due time as event start in:\n%s", content)
+	}
+}
+
 func Test_buildHtmlContent(t *testing.T) {
 	mock := &MockMailClient{}
-	service := NewEmailReminderService(mock, "sender@test.com", []string{"r@test.com"}, context.Background())
+	service := NewEmailReminderService(mock, config.EmailConfig{From: "sender@test.com", To: []string{"r@test.com"}}, context.Background(), nil)
 
 	var longMessageBuilder strings.Builder
 	for i := 1; i < 100; i++ {
@@ -60,7 +173,7 @@ func Test_buildHtmlContent(t *testing.T) {
 		{MessageText: longMessageBuilder.String(), PhoneNumber: "007", MailAddress: "test@mail.com"},
 	}
 
-	actual := service.buildHtmlContent(testSet)
+	actual, inlineImages := service.buildHtmlContent(testSet)
 
 	if strings.Count(actual, "<li>") != len(testSet) {
 		t.Errorf("Expected %d list items but found %d", len(testSet), strings.Count(actual, "<li>"))
@@ -68,4 +181,202 @@ func Test_buildHtmlContent(t *testing.T) {
 	if strings.Count(actual, "...") != 1 {
 		t.Errorf("Expected one element to be cut off but found %d elements", strings.Count(actual, "..."))
 	}
+	if len(inlineImages) != 0 || strings.Contains(actual, "<img") {
+		t.Errorf("Expected no QR codes but found %d", len(inlineImages))
+	}
+}
+
+func Test_buildMessage_Attachments(t *testing.T) {
+	message, err := buildMessage(MailRequest{
+		From:        "sender@test.com",
+		To:          []string{"a@test.com"},
+		Subject:     "subject",
+		HtmlContent: "<p>content</p>",
+		InlineImages: []Attachment{
+			{Filename: "qrcode-0.png", ContentType: "image/png", ContentID: "qrcode-0@whatsapp-reminder", Data: []byte("png")},
+		},
+		Attachments: []Attachment{
+			{Filename: "reminders.ics", ContentType: "text/calendar", Data: []byte("BEGIN:VCALENDAR")},
+		},
+	})
+
+	if err != nil {
+		t.Errorf("found error %+v", err)
+	}
+	if !strings.Contains(message, "\r\nContent-Type: multipart/mixed;") {
+		t.Errorf("Expected multipart/mixed message but got %q", message)
+	}
+	if !strings.Contains(message, "Content-Type: multipart/related;") {
+		t.Errorf("Expected nested multipart/related part but got %q", message)
+	}
+	if !strings.Contains(message, "Content-Disposition: attachment; filename=\"reminders.ics\"") {
+		t.Errorf("Expected ics attachment in %q", message)
+	}
+}
+
+func Test_buildHtmlContent_ContactName(t *testing.T) {
+	mock := &MockMailClient{}
+	service := NewEmailReminderService(mock, config.EmailConfig{From: "sender@test.com", To: []string{"r@test.com"}}, context.Background(), nil)
+	testSet := []dto.WhatsappReminderConfig{
+		{MessageText: "a", PhoneNumber: "491711234567", ContactName: "Anna"},
+		{MessageText: "b", PhoneNumber: "491711234567"},
+	}
+
+	actual, _ := service.buildHtmlContent(testSet)
+
+	if !strings.Contains(actual, "a (Anna (+491711234567))") {
+		t.Errorf("Expected contact name with number in %s", actual)
+	}
+	if !strings.Contains(actual, "b (491711234567)") {
+		t.Errorf("Expected plain number in %s", actual)
+	}
+}
+
+func Test_buildHtmlContent_AdvanceNotice(t *testing.T) {
+	mock := &MockMailClient{}
+	service := NewEmailReminderService(mock, config.EmailConfig{From: "sender@test.com", To: []string{"r@test.com"}}, context.Background(), nil)
+	testSet := []dto.WhatsappReminderConfig{
+		{MessageText: "buy a gift", DueTime: time.Date(2022, 07, 22, 15, 15, 0, 0, time.UTC), LeadTime: 24 * time.Hour},
+	}
+
+	actual, _ := service.buildHtmlContent(testSet)
+
+	if !strings.Contains(actual, "[upcoming 22/07/2022 15:15] buy a gift") {
+		t.Errorf("Expected advance notice in %s", actual)
+	}
+}
+
+func Test_Digest(t *testing.T) {
+	mock := &MockMailClient{}
+	cfg := config.EmailConfig{From: "sender@test.com", To: []string{"r@test.com"}, ICSAttachment: true}
+	service := NewEmailReminderService(mock, cfg, context.Background(), nil)
+	testSet := []dto.WhatsappReminderConfig{
+		{MessageText: "call mum", DueTime: time.Date(2022, 07, 22, 15, 15, 0, 0, time.UTC)},
+	}
+
+	err := service.Digest(testSet)
+
+	if err != nil {
+		t.Errorf("found error %+v", err)
+	}
+	if len(mock.SentMails) != 1 || mock.SentMails[0].Subject != mailDigestSubject {
+		t.Fatalf("Expected a single digest mail but got %+v", mock.SentMails)
+	}
+	if !strings.Contains(mock.SentMails[0].HtmlContent, "[skipped, due 22/07/2022 15:15] call mum") {
+		t.Errorf("Expected skipped reminder in %s", mock.SentMails[0].HtmlContent)
+	}
+	if len(mock.SentMails[0].Attachments) != 0 {
+		t.Errorf("Expected no calendar attachment for skipped reminders")
+	}
+
+	mock.SendError = errors.New("smtp error")
+	if err := service.Digest(testSet); err == nil {
+		t.Errorf("Expected error if digest could not be sent")
+	}
+}
+
+func Test_Escalate(t *testing.T) {
+	mock := &MockMailClient{}
+	cfg := config.EmailConfig{From: "sender@test.com", To: []string{"r@test.com"}, EscalationTo: []string{"partner@test.com"}}
+	service := NewEmailReminderService(mock, cfg, context.Background(), nil)
+	testSet := []dto.WhatsappReminderConfig{
+		{MessageText: "take pills", DueTime: time.Date(2022, 07, 22, 8, 0, 0, 0, time.UTC)},
+	}
+
+	err := service.Escalate(testSet)
+
+	if err != nil {
+		t.Errorf("found error %+v", err)
+	}
+	if len(mock.SentMails) != 1 || len(mock.SentMails[0].To) != 1 || mock.SentMails[0].To[0] != "partner@test.com" {
+		t.Fatalf("Expected a single mail to the escalation recipient but got %+v", mock.SentMails)
+	}
+	if !strings.Contains(mock.SentMails[0].HtmlContent, "[not acknowledged, due 22/07/2022 08:00] take pills") {
+		t.Errorf("Expected escalated reminder in %s", mock.SentMails[0].HtmlContent)
+	}
+}
+
+func Test_Escalate_WithoutRecipients(t *testing.T) {
+	mock := &MockMailClient{}
+	cfg := config.EmailConfig{From: "sender@test.com", To: []string{"r@test.com"}}
+	service := NewEmailReminderService(mock, cfg, context.Background(), nil)
+
+	err := service.Escalate([]dto.WhatsappReminderConfig{{MessageText: "take pills"}})
+
+	if err == nil {
+		t.Errorf("Expected error without escalation recipients")
+	}
+	if len(mock.SentMails) != 0 {
+		t.Errorf("Expected no mails but got %+v", mock.SentMails)
+	}
+}
+
+func Test_buildHtmlContent_ActionLinks(t *testing.T) {
+	mock := &MockMailClient{}
+	actionLinks := action.NewLinkCreator("https://reminder.example.com", action.NewSigner([]byte("secret")), time.Hour)
+	service := NewEmailReminderService(mock, config.EmailConfig{From: "sender@test.com", To: []string{"r@test.com"}}, context.Background(), actionLinks)
+	testSet := []dto.WhatsappReminderConfig{
+		{MessageText: "final", EntryID: "a"},
+		{MessageText: "notice", EntryID: "b", LeadTime: time.Hour},
+	}
+
+	actual, _ := service.buildHtmlContent(testSet)
+
+	if strings.Count(actual, "https://reminder.example.com/action?token=") != 3 {
+		t.Errorf("Expected three action links for the final reminder only in %s", actual)
+	}
+	for _, label := range []string{">Done<", ">Snooze 1h<", ">Snooze 1 day<"} {
+		if !strings.Contains(actual, label) {
+			t.Errorf("Expected %s in %s", label, actual)
+		}
+	}
+}
+
+func Test_buildHtmlContent_QRCodes(t *testing.T) {
+	mock := &MockMailClient{}
+	cfg := config.EmailConfig{From: "sender@test.com", To: []string{"r@test.com"}, QRCodes: true}
+	service := NewEmailReminderService(mock, cfg, context.Background(), nil)
+	testSet := []dto.WhatsappReminderConfig{
+		{MessageText: "a", PhoneNumber: "012"},
+		{MessageText: "b", PhoneNumber: "007"},
+	}
+
+	actual, inlineImages := service.buildHtmlContent(testSet)
+
+	if len(inlineImages) != len(testSet) {
+		t.Fatalf("Expected %d QR codes but found %d", len(testSet), len(inlineImages))
+	}
+	for _, image := range inlineImages {
+		if !strings.Contains(actual, "cid:"+image.ContentID) {
+			t.Errorf("Expected content to reference %s", image.ContentID)
+		}
+		if image.ContentType != "image/png" || len(image.Data) == 0 {
+			t.Errorf("Expected png image but got %s with %d bytes", image.ContentType, len(image.Data))
+		}
+	}
+}
+
+func Test_buildMessage_InlineImages(t *testing.T) {
+	message, err := buildMessage(MailRequest{
+		From:        "sender@test.com",
+		To:          []string{"a@test.com"},
+		Subject:     "subject",
+		HtmlContent: "<img src=\"cid:qrcode-0@whatsapp-reminder\"/>",
+		InlineImages: []Attachment{
+			{Filename: "qrcode-0.png", ContentType: "image/png", ContentID: "qrcode-0@whatsapp-reminder", Data: []byte("png")},
+		},
+	})
+
+	if err != nil {
+		t.Errorf("found error %+v", err)
+	}
+	if !strings.Contains(message, "Content-Type: multipart/related;") {
+		t.Errorf("Expected multipart/related message but got %q", message)
+	}
+	if strings.Contains(message, "multipart/mixed") {
+		t.Errorf("Expected no multipart/mixed message without attachments but got %q", message)
+	}
+	if !strings.Contains(message, "Content-ID: <qrcode-0@whatsapp-reminder>") {
+		t.Errorf("Expected Content-ID header in %q", message)
+	}
 }
@@ -1,11 +1,17 @@
 package reminder
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
+	"encoding/base64"
 	"fmt"
+	"io"
+	"mime/multipart"
 	"net"
 	"net/smtp"
+	"net/textproto"
+	"strings"
 
 	"github.com/jo-hoe/whatsapp-reminder/internal/config"
 )
@@ -17,10 +23,34 @@ type MailClientInterface interface {
 
 // MailRequest represents the data needed to send an email
 type MailRequest struct {
-	To          string
+	To          []string
+	Cc          []string
+	Bcc         []string
+	ReplyTo     string
 	Subject     string
 	HtmlContent string
 	From        string
+	// InlineImages are referenced from the HTML content via "cid:<ContentID>"
+	InlineImages []Attachment
+	Attachments  []Attachment
+}
+
+// Attachment represents a file sent along with an email
+type Attachment struct {
+	Filename    string
+	ContentType string
+	ContentID   string
+	Data        []byte
+}
+
+// Recipients returns all envelope recipients of the request.
+// Bcc recipients are part of the envelope but never of the headers.
+func (r MailRequest) Recipients() []string {
+	recipients := make([]string, 0, len(r.To)+len(r.Cc)+len(r.Bcc))
+	recipients = append(recipients, r.To...)
+	recipients = append(recipients, r.Cc...)
+	recipients = append(recipients, r.Bcc...)
+	return recipients
 }
 
 // MailClient sends email via SMTP
@@ -71,8 +101,10 @@ func (c *MailClient) send(addr string, request MailRequest) error {
 	if err := client.Mail(request.From); err != nil {
 		return fmt.Errorf("smtp MAIL FROM: %w", err)
 	}
-	if err := client.Rcpt(request.To); err != nil {
-		return fmt.Errorf("smtp RCPT TO: %w", err)
+	for _, recipient := range request.Recipients() {
+		if err := client.Rcpt(recipient); err != nil {
+			return fmt.Errorf("smtp RCPT TO %s: %w", recipient, err)
+		}
 	}
 
 	return c.writeBody(client, request)
@@ -105,10 +137,10 @@ func (c *MailClient) writeBody(client *smtp.Client, r MailRequest) error {
 		return fmt.Errorf("smtp DATA: %w", err)
 	}
 
-	msg := fmt.Sprintf(
-		"From: %s\r\nTo: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\nContent-Type: text/html; charset=\"UTF-8\"\r\n\r\n%s",
-		r.From, r.To, r.Subject, r.HtmlContent,
-	)
+	msg, err := buildMessage(r)
+	if err != nil {
+		return fmt.Errorf("smtp build body: %w", err)
+	}
 	if _, err := fmt.Fprint(w, msg); err != nil {
 		return fmt.Errorf("smtp write body: %w", err)
 	}
@@ -118,3 +150,118 @@ func (c *MailClient) writeBody(client *smtp.Client, r MailRequest) error {
 
 	return client.Quit()
 }
+
+func buildMessage(r MailRequest) (string, error) {
+	var stringBuilder strings.Builder
+	fmt.Fprintf(&stringBuilder, "From: %s\r\n", r.From)
+	if len(r.To) > 0 {
+		fmt.Fprintf(&stringBuilder, "To: %s\r\n", strings.Join(r.To, ", "))
+	}
+	if len(r.Cc) > 0 {
+		fmt.Fprintf(&stringBuilder, "Cc: %s\r\n", strings.Join(r.Cc, ", "))
+	}
+	if r.ReplyTo != "" {
+		fmt.Fprintf(&stringBuilder, "Reply-To: %s\r\n", r.ReplyTo)
+	}
+	fmt.Fprintf(&stringBuilder, "Subject: %s\r\n", r.Subject)
+	stringBuilder.WriteString("MIME-Version: 1.0\r\n")
+
+	contentType, body, err := buildBody(r)
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintf(&stringBuilder, "Content-Type: %s\r\n\r\n", contentType)
+	stringBuilder.Write(body)
+	return stringBuilder.String(), nil
+}
+
+// buildBody wraps the HTML content into multipart/mixed if attachments are present
+func buildBody(r MailRequest) (contentType string, body []byte, err error) {
+	contentType, body, err = buildHtmlBody(r)
+	if err != nil || len(r.Attachments) == 0 {
+		return contentType, body, err
+	}
+
+	var buffer bytes.Buffer
+	writer := multipart.NewWriter(&buffer)
+	part, err := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {contentType},
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	if _, err := part.Write(body); err != nil {
+		return "", nil, err
+	}
+	for _, attachment := range r.Attachments {
+		if err := writeAttachment(writer, attachment, "attachment"); err != nil {
+			return "", nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return "", nil, err
+	}
+
+	return fmt.Sprintf("multipart/mixed; boundary=\"%s\"", writer.Boundary()), buffer.Bytes(), nil
+}
+
+// buildHtmlBody wraps the HTML content into multipart/related if inline images are present
+func buildHtmlBody(r MailRequest) (contentType string, body []byte, err error) {
+	htmlContentType := "text/html; charset=\"UTF-8\""
+	if len(r.InlineImages) == 0 {
+		return htmlContentType, []byte(r.HtmlContent), nil
+	}
+
+	var buffer bytes.Buffer
+	writer := multipart.NewWriter(&buffer)
+	htmlPart, err := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {htmlContentType},
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	if _, err := io.WriteString(htmlPart, r.HtmlContent); err != nil {
+		return "", nil, err
+	}
+	for _, image := range r.InlineImages {
+		if err := writeAttachment(writer, image, "inline"); err != nil {
+			return "", nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return "", nil, err
+	}
+
+	return fmt.Sprintf("multipart/related; boundary=\"%s\"; type=\"text/html\"", writer.Boundary()), buffer.Bytes(), nil
+}
+
+func writeAttachment(writer *multipart.Writer, attachment Attachment, disposition string) error {
+	header := textproto.MIMEHeader{
+		"Content-Type":              {attachment.ContentType},
+		"Content-Transfer-Encoding": {"base64"},
+		"Content-Disposition":       {fmt.Sprintf("%s; filename=\"%s\"", disposition, attachment.Filename)},
+	}
+	if attachment.ContentID != "" {
+		// assigned directly as textproto would canonicalize the key to "Content-Id"
+		header["Content-ID"] = []string{"<" + attachment.ContentID + ">"}
+	}
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	return writeBase64(part, attachment.Data)
+}
+
+// writeBase64 writes data base64 encoded with lines wrapped at 76 characters as required by RFC 2045
+func writeBase64(w io.Writer, data []byte) error {
+	const lineLength = 76
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 0 {
+		n := min(lineLength, len(encoded))
+		if _, err := io.WriteString(w, encoded[:n]+"\r\n"); err != nil {
+			return err
+		}
+		encoded = encoded[n:]
+	}
+	return nil
+}
@@ -4,10 +4,16 @@ import "github.com/jo-hoe/whatsapp-reminder/internal/dto"
 
 type ReminderService interface {
 	Remind(messageConfigs []dto.WhatsappReminderConfig) (result []dto.WhatsappReminderConfig)
+	// Digest summarizes reminders which were skipped instead of sent
+	Digest(messageConfigs []dto.WhatsappReminderConfig) error
+	// Escalate notifies secondary recipients about reminders which were not acknowledged
+	Escalate(messageConfigs []dto.WhatsappReminderConfig) error
 }
 
 type ReminderMock struct {
-	RemindResult []dto.WhatsappReminderConfig
+	RemindResult   []dto.WhatsappReminderConfig
+	DigestResult   []dto.WhatsappReminderConfig
+	EscalateResult []dto.WhatsappReminderConfig
 }
 
 func (service *ReminderMock) Remind(messageConfigs []dto.WhatsappReminderConfig) (result []dto.WhatsappReminderConfig) {
@@ -15,3 +21,15 @@ func (service *ReminderMock) Remind(messageConfigs []dto.WhatsappReminderConfig)
 
 	return service.RemindResult
 }
+
+func (service *ReminderMock) Digest(messageConfigs []dto.WhatsappReminderConfig) error {
+	service.DigestResult = messageConfigs
+
+	return nil
+}
+
+func (service *ReminderMock) Escalate(messageConfigs []dto.WhatsappReminderConfig) error {
+	service.EscalateResult = messageConfigs
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package timeformat
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// localeDateLayouts are the date layouts of spreadsheet locales. Google Forms
+// writes the timestamps of responses in the locale of the spreadsheet.
+var localeDateLayouts = map[string]string{
+	"de-AT": "02.01.2006",
+	"de-CH": "02.01.2006",
+	"de-DE": "02.01.2006",
+	"en-AU": "02/01/2006",
+	"en-CA": "2006-01-02",
+	"en-GB": "02/01/2006",
+	"en-US": "1/2/2006",
+	"es-ES": "2/1/2006",
+	"fr-FR": "02/01/2006",
+	"it-IT": "2/1/2006",
+	"ja-JP": "2006/01/02",
+	"nl-NL": "2-1-2006",
+	"pl-PL": "2.01.2006",
+	"pt-BR": "02/01/2006",
+	"sv-SE": "2006-01-02",
+	"zh-CN": "2006/01/02",
+}
+
+// LocaleDateLayout returns the date layout of a spreadsheet locale, e.g. "en-US" or "de_DE"
+func LocaleDateLayout(locale string) (string, error) {
+	key := strings.ReplaceAll(strings.TrimSpace(locale), "_", "-")
+	for name, layout := range localeDateLayouts {
+		if strings.EqualFold(name, key) {
+			return layout, nil
+		}
+	}
+
+	known := make([]string, 0, len(localeDateLayouts))
+	for name := range localeDateLayouts {
+		known = append(known, name)
+	}
+	sort.Strings(known)
+	return "", fmt.Errorf("unknown locale '%s', known locales are: %s", locale, strings.Join(known, ", "))
+}
@@ -0,0 +1,242 @@
+package timeformat
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dates and times are written with the configured layouts and read with the
+// configured layouts, the input layouts, ISO 8601 and spreadsheet serial
+// numbers. seconds are optional for all time layouts and leading zeros are
+// optional for days and months. date times like timestamps are always written
+// with seconds, so they are read back unchanged.
+
+const (
+	DefaultDateLayout = "02/01/2006"
+	DefaultTimeLayout = "15:04:05"
+	secondsPerDay     = 24 * 60 * 60
+	// minSerialDate is the serial number of 01/01/1970, smaller numbers like
+	// a year "2022" are not read as dates
+	minSerialDate = 25569
+	// maxSerial is the serial number of 31/12/9999
+	maxSerial = 2958465
+)
+
+// serialEpoch is day zero of spreadsheet serial date numbers
+var serialEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+var (
+	isoDateLayouts     = []string{"2006-01-02"}
+	isoTimeLayouts     = []string{"15:04:05", "15:04:05.999999999"}
+	isoDateTimeLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999", "2006-01-02T15:04"}
+)
+
+type Format struct {
+	dateLayout string
+	timeLayout string
+	// secondsLayout is the time layout with seconds for date times
+	secondsLayout    string
+	inputDateLayouts []string
+	inputTimeLayouts []string
+}
+
+// Default returns the format used by the sheet template, e.g. "22/07/2022 15:15:15"
+func Default() Format {
+	format, _ := New("", "", nil, nil)
+	return format
+}
+
+// New creates a format from Go reference layouts, e.g. "02/01/2006" and "15:04:05".
+// Empty layouts fall back to the defaults, the input layouts are accepted when
+// reading in addition to the output layouts and are tried in the given order.
+func New(dateLayout string, timeLayout string, inputDateLayouts []string, inputTimeLayouts []string) (Format, error) {
+	if dateLayout == "" {
+		dateLayout = DefaultDateLayout
+	}
+	if timeLayout == "" {
+		timeLayout = DefaultTimeLayout
+	}
+
+	for _, layout := range append([]string{dateLayout}, inputDateLayouts...) {
+		if err := validateDateLayout(layout); err != nil {
+			return Format{}, err
+		}
+	}
+	for _, layout := range append([]string{timeLayout}, inputTimeLayouts...) {
+		if err := validateTimeLayout(layout); err != nil {
+			return Format{}, err
+		}
+	}
+
+	return Format{
+		dateLayout:       dateLayout,
+		timeLayout:       timeLayout,
+		secondsLayout:    withSeconds(timeLayout),
+		inputDateLayouts: withOptionalZeros(append(append([]string{dateLayout}, inputDateLayouts...), isoDateLayouts...)),
+		inputTimeLayouts: withOptionalSeconds(append(append([]string{timeLayout}, inputTimeLayouts...), isoTimeLayouts...)),
+	}, nil
+}
+
+func validateDateLayout(layout string) error {
+	reference := time.Date(2031, 11, 23, 0, 0, 0, 0, time.UTC)
+	parsed, err := time.Parse(layout, reference.Format(layout))
+	if err != nil || !parsed.Equal(reference) || reference.Format(layout) != reference.Add(13*time.Hour+14*time.Minute).Format(layout) {
+		return fmt.Errorf("date layout '%s' must contain day, month and year only, e.g. '02/01/2006'", layout)
+	}
+	return nil
+}
+
+func validateTimeLayout(layout string) error {
+	reference := time.Date(0, 1, 1, 13, 14, 0, 0, time.UTC)
+	parsed, err := time.Parse(layout, reference.Format(layout))
+	if err != nil || !parsed.Equal(reference) || reference.Format(layout) != reference.AddDate(1, 1, 1).Format(layout) {
+		return fmt.Errorf("time layout '%s' must contain hours and minutes only, e.g. '15:04:05'", layout)
+	}
+	return nil
+}
+
+// withOptionalZeros adds a variant without leading zeros of days and months for each layout
+func withOptionalZeros(layouts []string) []string {
+	return withVariants(layouts, strings.NewReplacer("02", "2", "01", "1").Replace)
+}
+
+// withOptionalSeconds adds a variant with or without seconds for each layout
+func withOptionalSeconds(layouts []string) []string {
+	return withVariants(layouts, func(layout string) string {
+		if strings.Contains(layout, ":05") {
+			return strings.Replace(layout, ":05", "", 1)
+		}
+		return withSeconds(layout)
+	})
+}
+
+// withSeconds adds seconds after the minutes of a layout without seconds
+func withSeconds(layout string) string {
+	if strings.Contains(layout, ":05") {
+		return layout
+	}
+	return strings.Replace(layout, "04", "04:05", 1)
+}
+
+func withVariants(layouts []string, variant func(layout string) string) []string {
+	result := make([]string, 0, 2*len(layouts))
+	seen := make(map[string]bool)
+	for _, layout := range layouts {
+		for _, candidate := range []string{layout, variant(layout)} {
+			if !seen[candidate] {
+				seen[candidate] = true
+				result = append(result, candidate)
+			}
+		}
+	}
+	return result
+}
+
+// FormatDate formats the date with the output layout
+func (format Format) FormatDate(t time.Time) string {
+	return t.Format(format.dateLayout)
+}
+
+// FormatTime formats the clock time with the output layout
+func (format Format) FormatTime(t time.Time) string {
+	return t.Format(format.timeLayout)
+}
+
+// FormatDateTime formats date and clock time with seconds separated by a space
+func (format Format) FormatDateTime(t time.Time) string {
+	return format.FormatDate(t) + " " + t.Format(format.secondsLayout)
+}
+
+// ParseDateAndTime combines a date and a clock time given in separate cells
+func (format Format) ParseDateAndTime(dateValue string, timeValue string, location *time.Location) (time.Time, error) {
+	date, err := format.parseDate(dateValue)
+	if err != nil {
+		return time.Time{}, err
+	}
+	clock, err := format.parseTime(timeValue)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return combine(date, clock, location), nil
+}
+
+// ParseDateTime parses a date with a clock time in a single cell, e.g. a timestamp
+func (format Format) ParseDateTime(value string, location *time.Location) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range isoDateTimeLayouts {
+		if result, err := time.ParseInLocation(layout, value, location); err == nil {
+			return result, nil
+		}
+	}
+	if days, ok := parseSerialDate(value); ok {
+		return combine(serialEpoch.AddDate(0, 0, int(days)), serialClock(days), location), nil
+	}
+
+	// the date and the clock time are separated by the last space
+	// which does not belong to the time layout, e.g. "01/02/2006 3:04 PM"
+	for i := len(value) - 1; i > 0; i-- {
+		if value[i] != ' ' {
+			continue
+		}
+		if result, err := format.ParseDateAndTime(value[:i], value[i+1:], location); err == nil {
+			return result, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("could not parse date and time '%s'", value)
+}
+
+func (format Format) parseDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range format.inputDateLayouts {
+		if result, err := time.Parse(layout, value); err == nil {
+			return result, nil
+		}
+	}
+	if days, ok := parseSerialDate(value); ok {
+		return serialEpoch.AddDate(0, 0, int(days)), nil
+	}
+	return time.Time{}, fmt.Errorf("could not parse date '%s'", value)
+}
+
+func (format Format) parseTime(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range format.inputTimeLayouts {
+		if result, err := time.Parse(layout, value); err == nil {
+			return result, nil
+		}
+	}
+	// clock times are fractions of a day in spreadsheets
+	if fraction, ok := parseSerial(value); ok && fraction < 1 {
+		return serialClock(fraction), nil
+	}
+	return time.Time{}, fmt.Errorf("could not parse time '%s'", value)
+}
+
+// parseSerial parses a spreadsheet serial number, the days since 30/12/1899
+func parseSerial(value string) (float64, bool) {
+	serial, err := strconv.ParseFloat(value, 64)
+	if err != nil || serial < 0 || serial > maxSerial {
+		return 0, false
+	}
+	return serial, true
+}
+
+// parseSerialDate parses the serial number of a date since 01/01/1970
+func parseSerialDate(value string) (float64, bool) {
+	serial, ok := parseSerial(value)
+	return serial, ok && serial >= minSerialDate
+}
+
+// serialClock returns the clock time of the fraction of a serial number
+func serialClock(serial float64) time.Time {
+	_, fraction := math.Modf(serial)
+	seconds := int(math.Round(fraction * secondsPerDay))
+	return time.Time{}.Add(time.Duration(seconds) * time.Second)
+}
+
+func combine(date time.Time, clock time.Time, location *time.Location) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), clock.Hour(), clock.Minute(), clock.Second(), clock.Nanosecond(), location)
+}
@@ -0,0 +1,238 @@
+package timeformat
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormat_ParseDateAndTime(t *testing.T) {
+	usFormat, err := New("", "", []string{"01/02/2006"}, []string{"3:04 PM"})
+	if err != nil {
+		t.Fatalf("found error %+v", err)
+	}
+
+	tests := []struct {
+		name      string
+		format    Format
+		dateValue string
+		timeValue string
+		want      time.Time
+		wantErr   bool
+	}{
+		{
+			name:      "default",
+			format:    Default(),
+			dateValue: "22/07/2022",
+			timeValue: "15:15:15",
+			want:      time.Date(2022, 7, 22, 15, 15, 15, 0, time.UTC),
+		}, {
+			name:      "without seconds and leading zeros",
+			format:    Default(),
+			dateValue: "2/7/2022",
+			timeValue: "9:30",
+			want:      time.Date(2022, 7, 2, 9, 30, 0, 0, time.UTC),
+		}, {
+			name:      "iso",
+			format:    Default(),
+			dateValue: "2022-07-22",
+			timeValue: "15:15",
+			want:      time.Date(2022, 7, 22, 15, 15, 0, 0, time.UTC),
+		}, {
+			name:      "serial numbers",
+			format:    Default(),
+			dateValue: "44764",
+			timeValue: "0.375",
+			want:      time.Date(2022, 7, 22, 9, 0, 0, 0, time.UTC),
+		}, {
+			name:      "output layout takes precedence over input layouts",
+			format:    usFormat,
+			dateValue: "02/07/2022",
+			timeValue: "3:15 PM",
+			want:      time.Date(2022, 7, 2, 15, 15, 0, 0, time.UTC),
+		}, {
+			name:      "input layout",
+			format:    usFormat,
+			dateValue: "07/22/2022",
+			timeValue: "3:15:10 PM",
+			want:      time.Date(2022, 7, 22, 15, 15, 10, 0, time.UTC),
+		}, {
+			name:      "unknown date",
+			format:    Default(),
+			dateValue: "07/22/2022",
+			timeValue: "15:15",
+			wantErr:   true,
+		}, {
+			name:      "year is no serial date",
+			format:    Default(),
+			dateValue: "2022",
+			timeValue: "15:15",
+			wantErr:   true,
+		}, {
+			name:      "serial number is no clock time",
+			format:    Default(),
+			dateValue: "22/07/2022",
+			timeValue: "9",
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.format.ParseDateAndTime(tt.dateValue, tt.timeValue, time.UTC)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDateAndTime() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("ParseDateAndTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormat_ParseDateTime(t *testing.T) {
+	location, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("found error %+v", err)
+	}
+	usFormat, err := New("01/02/2006", "3:04 PM", nil, nil)
+	if err != nil {
+		t.Fatalf("found error %+v", err)
+	}
+
+	tests := []struct {
+		name    string
+		format  Format
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{
+			name:   "default",
+			format: Default(),
+			value:  "20/07/2022 13:13:13",
+			want:   time.Date(2022, 7, 20, 13, 13, 13, 0, location),
+		}, {
+			name:   "iso with offset",
+			format: Default(),
+			value:  "2022-07-20T13:13:13Z",
+			want:   time.Date(2022, 7, 20, 15, 13, 13, 0, location),
+		}, {
+			name:   "iso without offset",
+			format: Default(),
+			value:  "2022-07-20 13:13",
+			want:   time.Date(2022, 7, 20, 13, 13, 0, 0, location),
+		}, {
+			name:   "serial number",
+			format: Default(),
+			value:  "44762.5",
+			want:   time.Date(2022, 7, 20, 12, 0, 0, 0, location),
+		}, {
+			name:    "year is no serial date",
+			format:  Default(),
+			value:   "2022",
+			wantErr: true,
+		}, {
+			name:   "time layout with space",
+			format: usFormat,
+			value:  "7/20/2022 1:13 PM",
+			want:   time.Date(2022, 7, 20, 13, 13, 0, 0, location),
+		}, {
+			name:    "invalid",
+			format:  Default(),
+			value:   "yesterday",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.format.ParseDateTime(tt.value, location)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDateTime() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("ParseDateTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormat_FormatDateTime(t *testing.T) {
+	format, err := New("2006-01-02", "15:04", nil, nil)
+	if err != nil {
+		t.Fatalf("found error %+v", err)
+	}
+	value := time.Date(2022, 7, 20, 13, 13, 13, 0, time.UTC)
+
+	if got := Default().FormatDateTime(value); got != "20/07/2022 13:13:13" {
+		t.Errorf("FormatDateTime() = %s", got)
+	}
+	if got := format.FormatDateTime(value); got != "2022-07-20 13:13:13" {
+		t.Errorf("FormatDateTime() = %s", got)
+	}
+	format, err = New("2006-01-02", "3:04 PM", nil, nil)
+	if err != nil {
+		t.Fatalf("found error %+v", err)
+	}
+	if got := format.FormatDateTime(value); got != "2022-07-20 1:13:13 PM" {
+		t.Errorf("FormatDateTime() = %s", got)
+	}
+	if got := format.FormatTime(value); got != "1:13 PM" {
+		t.Errorf("FormatTime() = %s", got)
+	}
+}
+
+func TestNew_InvalidLayouts(t *testing.T) {
+	tests := []struct {
+		name       string
+		dateLayout string
+		timeLayout string
+	}{
+		{name: "date without year", dateLayout: "02/01"},
+		{name: "date with time", dateLayout: "02/01/2006 15:04"},
+		{name: "not a layout", dateLayout: "DD/MM/YYYY"},
+		{name: "time without minutes", timeLayout: "15"},
+		{name: "time with date", timeLayout: "2006 15:04"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.dateLayout, tt.timeLayout, nil, nil); err == nil {
+				t.Errorf("expected error for layouts '%s' and '%s'", tt.dateLayout, tt.timeLayout)
+			}
+		})
+	}
+}
+
+func TestLocaleDateLayout(t *testing.T) {
+	tests := []struct {
+		locale   string
+		value    string
+		expected time.Time
+		wantErr  bool
+	}{
+		{locale: "en-US", value: "7/20/2022 13:05:09", expected: time.Date(2022, 7, 20, 13, 5, 9, 0, time.UTC)},
+		{locale: "de_DE", value: "20.07.2022 13:05:09", expected: time.Date(2022, 7, 20, 13, 5, 9, 0, time.UTC)},
+		{locale: "ja-jp", value: "2022/07/20 13:05", expected: time.Date(2022, 7, 20, 13, 5, 0, 0, time.UTC)},
+		{locale: "xx-XX", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			layout, err := LocaleDateLayout(tt.locale)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LocaleDateLayout() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			format, err := New("", "", []string{layout}, nil)
+			if err != nil {
+				t.Fatalf("found error %+v", err)
+			}
+			actual, err := format.ParseDateTime(tt.value, time.UTC)
+			if err != nil {
+				t.Fatalf("found error %+v", err)
+			}
+			if !actual.Equal(tt.expected) {
+				t.Errorf("expected %v but got %v", tt.expected, actual)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package whatsapp
 
 import (
+	"fmt"
 	"net/url"
 	"regexp"
 	"strings"
@@ -14,26 +15,119 @@ import (
 // https://wa.me/15551234567
 // example with only text
 // https://wa.me/?text=urlencodedtext
+// other link styles use query parameters for the phone number, e.g.
+// https://api.whatsapp.com/send?phone=15551234567&text=urlencodedtext
+// whatsapp://send?phone=15551234567&text=urlencodedtext
+// group invites do not support a message text
+// https://chat.whatsapp.com/AbCdEfGhIjKlMnOpQrStUv
 
-const baseURL = "https://wa.me/"
+type LinkStyle string
+
+const (
+	// LinkStyleWaMe opens WhatsApp or WhatsApp Web via https://wa.me
+	LinkStyleWaMe LinkStyle = "wa.me"
+	// LinkStyleAPI opens WhatsApp or WhatsApp Web via https://api.whatsapp.com/send
+	LinkStyleAPI LinkStyle = "api"
+	// LinkStyleApp opens the installed WhatsApp app via the whatsapp:// scheme
+	LinkStyleApp LinkStyle = "app"
+	// LinkStyleBusiness opens the installed WhatsApp Business app via the whatsapp-business:// scheme
+	LinkStyleBusiness LinkStyle = "business"
+)
+
+const (
+	baseURL        = "https://wa.me/"
+	apiURL         = "https://api.whatsapp.com/send"
+	appURL         = "whatsapp://send"
+	businessAppURL = "whatsapp-business://send"
+	groupInviteURL = "https://chat.whatsapp.com/"
+)
+
+var (
+	whitespaceRegex      = regexp.MustCompile(`\s+`)
+	groupInviteLinkRegex = regexp.MustCompile(`^(?:https?://)?chat\.whatsapp\.com/(?:invite/)?([A-Za-z0-9]+)/?$`)
+	groupInviteCodeRegex = regexp.MustCompile(`^[A-Za-z0-9]{20,24}$`)
+	letterRegex          = regexp.MustCompile(`[A-Za-z]`)
+)
+
+// ParseLinkStyle validates the link style. An empty style defaults to wa.me.
+func ParseLinkStyle(style string) (LinkStyle, error) {
+	switch LinkStyle(style) {
+	case "":
+		return LinkStyleWaMe, nil
+	case LinkStyleWaMe, LinkStyleAPI, LinkStyleApp, LinkStyleBusiness:
+		return LinkStyle(style), nil
+	}
+	return "", fmt.Errorf("unknown link style '%s', expected one of %s, %s, %s, %s",
+		style, LinkStyleWaMe, LinkStyleAPI, LinkStyleApp, LinkStyleBusiness)
+}
 
 func CreateWhatsappLink(phoneNumber string, messageText string) (link string) {
+	return CreateLink(LinkStyleWaMe, phoneNumber, messageText)
+}
+
+// CreateLink creates a link in the given style. If the phone number is a
+// group invite code or link, a group invite link is returned instead.
+func CreateLink(style LinkStyle, phoneNumber string, messageText string) (link string) {
+	if code, ok := ParseGroupInviteCode(phoneNumber); ok {
+		return groupInviteURL + code
+	}
+
+	phoneNumberWithoutWhitespace := removeWhiteSpaces(phoneNumber)
+	switch style {
+	case LinkStyleAPI:
+		return createQueryLink(apiURL, phoneNumberWithoutWhitespace, messageText)
+	case LinkStyleApp:
+		return createQueryLink(appURL, phoneNumberWithoutWhitespace, messageText)
+	case LinkStyleBusiness:
+		return createQueryLink(businessAppURL, phoneNumberWithoutWhitespace, messageText)
+	}
+
 	var stringBuilder strings.Builder
 	stringBuilder.WriteString(baseURL)
-	phoneNumberWithoutWhitespace := removeWhiteSpaces(phoneNumber)
 	stringBuilder.WriteString(url.PathEscape(phoneNumberWithoutWhitespace))
 	if len(messageText) > 0 {
 		stringBuilder.WriteString("?text=")
-		stringBuilder.WriteString(url.PathEscape(messageText))
+		stringBuilder.WriteString(escapeText(messageText))
 	}
 	return stringBuilder.String()
 }
 
+// ParseGroupInviteCode returns the invite code if the value is a group invite
+// link or a bare invite code. Bare codes have to contain at least one letter
+// to be distinguishable from phone numbers.
+func ParseGroupInviteCode(value string) (code string, ok bool) {
+	trimmed := strings.TrimSpace(value)
+	if match := groupInviteLinkRegex.FindStringSubmatch(trimmed); match != nil {
+		return match[1], true
+	}
+	if groupInviteCodeRegex.MatchString(trimmed) && letterRegex.MatchString(trimmed) {
+		return trimmed, true
+	}
+	return "", false
+}
+
+func createQueryLink(base string, phoneNumber string, messageText string) string {
+	params := make([]string, 0, 2)
+	if len(phoneNumber) > 0 {
+		params = append(params, "phone="+url.QueryEscape(phoneNumber))
+	}
+	if len(messageText) > 0 {
+		params = append(params, "text="+escapeText(messageText))
+	}
+	if len(params) == 0 {
+		return base
+	}
+	return base + "?" + strings.Join(params, "&")
+}
+
+// escapeText query escapes the text but encodes spaces as %20 like the WhatsApp documentation does
+func escapeText(text string) string {
+	return strings.ReplaceAll(url.QueryEscape(text), "+", "%20")
+}
+
 func removeWhiteSpaces(str string) string {
 	if str == "" {
 		return ""
 	}
-	//A regular expression that matches one or more whitespace characters
-	reg := regexp.MustCompile(`\s+`)
-	return reg.ReplaceAllString(str, "")
+	return whitespaceRegex.ReplaceAllString(str, "")
 }
@@ -47,6 +47,13 @@ func TestLinkCreator_CreateWhatsappLink(t *testing.T) {
 				phoneNumber: "",
 				messageText: "Hallo 😉",
 			},
+		}, {
+			name:     "Reserved characters in text",
+			wantLink: "https://wa.me/15551234567?text=1%2B1%3D2%20%26%20more",
+			args: args{
+				phoneNumber: "15551234567",
+				messageText: "1+1=2 & more",
+			},
 		}, {
 			name:     "Whitespace in phone number",
 			wantLink: "https://wa.me/+49123456789?text=test",
@@ -65,3 +72,94 @@ func TestLinkCreator_CreateWhatsappLink(t *testing.T) {
 		})
 	}
 }
+
+func TestLinkCreator_CreateLink(t *testing.T) {
+	type args struct {
+		style       LinkStyle
+		phoneNumber string
+		messageText string
+	}
+	tests := []struct {
+		name     string
+		args     args
+		wantLink string
+	}{
+		{
+			name:     "wa.me",
+			wantLink: "https://wa.me/15551234567?text=Hello%20World",
+			args:     args{style: LinkStyleWaMe, phoneNumber: "15551234567", messageText: "Hello World"},
+		}, {
+			name:     "API",
+			wantLink: "https://api.whatsapp.com/send?phone=15551234567&text=Hello%20World",
+			args:     args{style: LinkStyleAPI, phoneNumber: "15551234567", messageText: "Hello World"},
+		}, {
+			name:     "App",
+			wantLink: "whatsapp://send?phone=15551234567&text=Hello%20World",
+			args:     args{style: LinkStyleApp, phoneNumber: "15551234567", messageText: "Hello World"},
+		}, {
+			name:     "Business",
+			wantLink: "whatsapp-business://send?phone=15551234567&text=Hello%20World",
+			args:     args{style: LinkStyleBusiness, phoneNumber: "15551234567", messageText: "Hello World"},
+		}, {
+			name:     "App without phone number",
+			wantLink: "whatsapp://send?text=Hello",
+			args:     args{style: LinkStyleApp, messageText: "Hello"},
+		}, {
+			name:     "API without parameters",
+			wantLink: "https://api.whatsapp.com/send",
+			args:     args{style: LinkStyleAPI},
+		}, {
+			name:     "Group invite link",
+			wantLink: "https://chat.whatsapp.com/AbCdEfGhIjKlMnOpQrStUv",
+			args:     args{style: LinkStyleApp, phoneNumber: "https://chat.whatsapp.com/AbCdEfGhIjKlMnOpQrStUv", messageText: "Hello"},
+		}, {
+			name:     "Group invite code",
+			wantLink: "https://chat.whatsapp.com/AbCdEfGhIjKlMnOpQrStUv",
+			args:     args{style: LinkStyleWaMe, phoneNumber: " AbCdEfGhIjKlMnOpQrStUv ", messageText: "Hello"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotLink := CreateLink(tt.args.style, tt.args.phoneNumber, tt.args.messageText)
+			if gotLink != tt.wantLink {
+				t.Errorf("CreateLink() = %v, want %v", gotLink, tt.wantLink)
+			}
+		})
+	}
+}
+
+func TestLinkCreator_ParseGroupInviteCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		wantCode string
+		wantOk   bool
+	}{
+		{name: "Link", value: "https://chat.whatsapp.com/AbCdEfGhIjKlMnOpQrStUv", wantCode: "AbCdEfGhIjKlMnOpQrStUv", wantOk: true},
+		{name: "Link without scheme", value: "chat.whatsapp.com/invite/AbCdEfGhIjKlMnOpQrStUv", wantCode: "AbCdEfGhIjKlMnOpQrStUv", wantOk: true},
+		{name: "Code", value: "AbCdEfGhIjKlMnOpQrStUv", wantCode: "AbCdEfGhIjKlMnOpQrStUv", wantOk: true},
+		{name: "Phone number", value: "+49 171 1234567", wantOk: false},
+		{name: "Long phone number", value: "4917112345678901234567", wantOk: false},
+		{name: "Empty", value: "", wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotCode, gotOk := ParseGroupInviteCode(tt.value)
+			if gotCode != tt.wantCode || gotOk != tt.wantOk {
+				t.Errorf("ParseGroupInviteCode() = (%v, %v), want (%v, %v)", gotCode, gotOk, tt.wantCode, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestLinkCreator_ParseLinkStyle(t *testing.T) {
+	if style, err := ParseLinkStyle(""); err != nil || style != LinkStyleWaMe {
+		t.Errorf("expected default style %s but got %s (%v)", LinkStyleWaMe, style, err)
+	}
+	if style, err := ParseLinkStyle("business"); err != nil || style != LinkStyleBusiness {
+		t.Errorf("expected style %s but got %s (%v)", LinkStyleBusiness, style, err)
+	}
+	if _, err := ParseLinkStyle("telegram"); err == nil {
+		t.Errorf("expected error for unknown style")
+	}
+}
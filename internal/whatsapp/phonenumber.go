@@ -0,0 +1,93 @@
+package whatsapp
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// normalizes phone numbers into the format expected by wa.me
+// which is the E.164 number without the leading plus, e.g.
+// +49 (0)171-123456 -> 49171123456
+// 0049 171 123456   -> 49171123456
+// 0171 123456       -> 49171123456 (with default country code 49)
+// (0)171 123456     -> 49171123456 (with default country code 49)
+
+var ErrInvalidPhoneNumber = errors.New("invalid phone number")
+
+const (
+	minPhoneNumberDigits = 7
+	maxPhoneNumberDigits = 15
+)
+
+var (
+	trunkPrefixRegex = regexp.MustCompile(`\(\s*0\s*\)`)
+	separatorRegex   = regexp.MustCompile(`[\s\-./()]+`)
+	digitsRegex      = regexp.MustCompile(`^[0-9]+$`)
+	countryCodeRegex = regexp.MustCompile(`^[1-9][0-9]{0,2}$`)
+)
+
+// NormalizePhoneNumber returns the phone number as digits only including the
+// country code. National numbers starting with a single 0 are prefixed with
+// the default country code. Empty phone numbers are returned unchanged.
+func NormalizePhoneNumber(phoneNumber string, defaultCountryCode string) (string, error) {
+	number := strings.TrimSpace(phoneNumber)
+	if number == "" {
+		return "", nil
+	}
+
+	// the trunk prefix "(0)" is dialed within the country only, so it is
+	// dropped after a country code and kept in national numbers
+	if strings.HasPrefix(number, "+") || strings.HasPrefix(number, "00") {
+		number = trunkPrefixRegex.ReplaceAllString(number, "")
+	} else {
+		number = trunkPrefixRegex.ReplaceAllString(number, "0")
+	}
+	number = separatorRegex.ReplaceAllString(number, "")
+
+	switch {
+	case strings.HasPrefix(number, "+"):
+		number = number[1:]
+	case strings.HasPrefix(number, "00"):
+		number = number[2:]
+	case strings.HasPrefix(number, "0"):
+		countryCode, err := NormalizeCountryCode(defaultCountryCode)
+		if err != nil {
+			return "", err
+		}
+		if countryCode == "" {
+			return "", fmt.Errorf("%w: '%s' is a national number but no default country code is configured", ErrInvalidPhoneNumber, phoneNumber)
+		}
+		number = countryCode + number[1:]
+	}
+
+	if !digitsRegex.MatchString(number) {
+		return "", fmt.Errorf("%w: '%s' contains characters other than digits", ErrInvalidPhoneNumber, phoneNumber)
+	}
+	if strings.HasPrefix(number, "0") {
+		return "", fmt.Errorf("%w: '%s' has no valid country code", ErrInvalidPhoneNumber, phoneNumber)
+	}
+	if len(number) < minPhoneNumberDigits || len(number) > maxPhoneNumberDigits {
+		return "", fmt.Errorf("%w: '%s' must have between %d and %d digits", ErrInvalidPhoneNumber, phoneNumber, minPhoneNumberDigits, maxPhoneNumberDigits)
+	}
+
+	return number, nil
+}
+
+// NormalizeCountryCode accepts country codes like "49", "+49" or "0049"
+// and returns the digits only. An empty country code is returned unchanged.
+func NormalizeCountryCode(countryCode string) (string, error) {
+	code := strings.TrimSpace(countryCode)
+	if code == "" {
+		return "", nil
+	}
+	code = strings.TrimPrefix(code, "+")
+	if strings.HasPrefix(code, "00") {
+		code = code[2:]
+	}
+	if !countryCodeRegex.MatchString(code) {
+		return "", fmt.Errorf("invalid country code '%s'", countryCode)
+	}
+	return code, nil
+}
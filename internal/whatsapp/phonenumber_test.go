@@ -0,0 +1,100 @@
+package whatsapp
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalizePhoneNumber(t *testing.T) {
+	type args struct {
+		phoneNumber        string
+		defaultCountryCode string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "Already normalized",
+			args: args{phoneNumber: "15551234567"},
+			want: "15551234567",
+		}, {
+			name: "Plus prefix",
+			args: args{phoneNumber: "+49 171 1234567"},
+			want: "491711234567",
+		}, {
+			name: "Double zero prefix",
+			args: args{phoneNumber: "0049 171 1234567"},
+			want: "491711234567",
+		}, {
+			name: "Trunk prefix",
+			args: args{phoneNumber: "+49 (0)171-1234567"},
+			want: "491711234567",
+		}, {
+			name: "Trunk prefix with spaces after double zero prefix",
+			args: args{phoneNumber: "0049 ( 0 ) 171 1234567"},
+			want: "491711234567",
+		}, {
+			name: "Trunk prefix of national number",
+			args: args{phoneNumber: "(0)171 1234567", defaultCountryCode: "49"},
+			want: "491711234567",
+		}, {
+			name:    "Trunk prefix of national number without default country code",
+			args:    args{phoneNumber: "(0)171 1234567"},
+			wantErr: true,
+		}, {
+			name: "Parentheses and dashes",
+			args: args{phoneNumber: "+1 (555) 123-4567"},
+			want: "15551234567",
+		}, {
+			name: "National number with default country code",
+			args: args{phoneNumber: "0171 1234567", defaultCountryCode: "+49"},
+			want: "491711234567",
+		}, {
+			name:    "National number without default country code",
+			args:    args{phoneNumber: "0171 1234567"},
+			wantErr: true,
+		}, {
+			name: "Empty",
+			args: args{phoneNumber: " "},
+			want: "",
+		}, {
+			name:    "Letters",
+			args:    args{phoneNumber: "+49 171 CALLME"},
+			wantErr: true,
+		}, {
+			name:    "Too short",
+			args:    args{phoneNumber: "+49 171"},
+			wantErr: true,
+		}, {
+			name:    "Too long",
+			args:    args{phoneNumber: "+49 171 1234567890123"},
+			wantErr: true,
+		}, {
+			name:    "Invalid default country code",
+			args:    args{phoneNumber: "0171 1234567", defaultCountryCode: "abc"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizePhoneNumber(tt.args.phoneNumber, tt.args.defaultCountryCode)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NormalizePhoneNumber() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("NormalizePhoneNumber() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizePhoneNumber_ErrorType(t *testing.T) {
+	_, err := NormalizePhoneNumber("0171 1234567", "")
+	if !errors.Is(err, ErrInvalidPhoneNumber) {
+		t.Errorf("expected ErrInvalidPhoneNumber but got %v", err)
+	}
+}